@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+type (
+	// OpenTelemetryTargetAllocatorAllocationStrategy represents which strategy the target allocator uses to distribute targets among the collectors
+	// +kubebuilder:validation:Enum=least-weighted;consistent-hashing;per-node
+	OpenTelemetryTargetAllocatorAllocationStrategy string
+)
+
+const (
+	// OpenTelemetryTargetAllocatorAllocationStrategyLeastWeighted assigns each new target to the collector with the fewest targets.
+	OpenTelemetryTargetAllocatorAllocationStrategyLeastWeighted OpenTelemetryTargetAllocatorAllocationStrategy = "least-weighted"
+
+	// OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing assigns targets using a consistent hash ring,
+	// so that only a fraction of the targets move when collectors are added or removed.
+	OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing OpenTelemetryTargetAllocatorAllocationStrategy = "consistent-hashing"
+
+	// OpenTelemetryTargetAllocatorAllocationStrategyPerNode assigns every target to the collector running on the same node,
+	// which requires the collectors to run in daemonset mode.
+	OpenTelemetryTargetAllocatorAllocationStrategyPerNode OpenTelemetryTargetAllocatorAllocationStrategy = "per-node"
+)
@@ -0,0 +1,33 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+const (
+	// ConditionTypeReady is true when all the collector instances run the current spec and are available.
+	ConditionTypeReady = "Ready"
+
+	// ConditionTypeProgressing is true while the collector instances are being created or updated.
+	ConditionTypeProgressing = "Progressing"
+
+	// ConditionTypeDegraded is true when the collector can't be reconciled, or its rollout failed.
+	ConditionTypeDegraded = "Degraded"
+
+	// ConditionTypeConfigValid is true when the collector's configuration could be parsed.
+	ConditionTypeConfigValid = "ConfigValid"
+
+	// ConditionTypeTargetAllocatorReady is true when all the target allocator instances are available. It is only
+	// set when the target allocator is enabled.
+	ConditionTypeTargetAllocatorReady = "TargetAllocatorReady"
+)
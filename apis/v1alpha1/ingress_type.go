@@ -0,0 +1,27 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+type (
+	// IngressType represents how the receivers of the collector are exposed outside of the cluster.
+	// +kubebuilder:validation:Enum=ingress
+	IngressType string
+)
+
+const (
+	// IngressTypeIngress specifies that the receivers should be exposed with Kubernetes Ingresses,
+	// or with OpenShift Routes when the operator runs on OpenShift.
+	IngressTypeIngress IngressType = "ingress"
+)
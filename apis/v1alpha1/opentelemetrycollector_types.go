@@ -16,6 +16,7 @@ package v1alpha1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -57,6 +58,11 @@ type OpenTelemetryCollectorSpec struct {
 	// +optional
 	Mode Mode `json:"mode,omitempty"`
 
+	// Ingress configures how the receivers of the collector are exposed outside of the cluster.
+	// Not available in sidecar mode.
+	// +optional
+	Ingress Ingress `json:"ingress,omitempty"`
+
 	// ServiceAccount indicates the name of an existing service account to use with this instance.
 	// +optional
 	ServiceAccount string `json:"serviceAccount,omitempty"`
@@ -123,26 +129,112 @@ type OpenTelemetryCollectorSpec struct {
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 }
 
+// Ingress defines how the receivers of the collector are exposed outside of the cluster. Each port inferred from the
+// receivers of the configuration is exposed at its own host, `<port name>.<hostname>`, so that the gRPC receivers,
+// whose paths can't be prefixed, can be exposed next to the HTTP ones.
+type Ingress struct {
+	// Type enables the exposure of the receivers when set. The only option is ingress, which creates Ingresses,
+	// or Routes when the operator runs on OpenShift.
+	// +optional
+	Type IngressType `json:"type,omitempty"`
+
+	// Hostname is the domain the receivers are exposed under. Required when Type is set.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Annotations to add to the Ingresses or Routes, for example to configure the ingress controller.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TLS configures the TLS of the Ingresses. On OpenShift, a non-empty TLS enables the edge termination of
+	// the Routes, with the certificate of the router.
+	// +optional
+	// +listType=atomic
+	TLS []networkingv1.IngressTLS `json:"tls,omitempty"`
+
+	// IngressClassName is the name of the IngressClass the Ingresses belong to. Not used for Routes.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+}
+
 // OpenTelemetryTargetAllocator defines the configurations for the Prometheus target allocator.
 type OpenTelemetryTargetAllocator struct {
 	// Enabled indicates whether to use a target allocation mechanism for Prometheus targets or not.
 	// +optional
 	Enabled bool `json:"enabled,omitempty"`
 
-	// PrometheusCR defines the configuration for the retrieval of PrometheusOperator CRDs ( servicemonitor.monitoring.coreos.com/v1 and podmonitor.monitoring.coreos.com/v1 )  retrieval.
-	// All CR instances which the ServiceAccount has access to will be retrieved. This includes other namespaces.
+	// PrometheusCR defines the configuration for the retrieval of PrometheusOperator CRDs ( servicemonitor.monitoring.coreos.com/v1, podmonitor.monitoring.coreos.com/v1 and probe.monitoring.coreos.com/v1 )  retrieval.
+	// Unless restricted by its selectors, all CR instances which the ServiceAccount has access to will be retrieved. This includes other namespaces.
 	// +optional
 	PrometheusCR OpenTelemetryTargetAllocatorPrometheusCR `json:"prometheusCR,omitempty"`
 
 	// Image indicates the container image to use for the OpenTelemetry TargetAllocator.
 	// +optional
 	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of pods of the TargetAllocator. With more than one replica, the allocation strategy
+	// must be consistent-hashing, so that all replicas allocate the targets the same way. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// AllocationStrategy determines which strategy the target allocator should use for allocation.
+	// The current options are least-weighted, consistent-hashing and per-node. The default is least-weighted,
+	// or per-node in daemonset mode.
+	// +optional
+	AllocationStrategy OpenTelemetryTargetAllocatorAllocationStrategy `json:"allocationStrategy,omitempty"`
+
+	// TLS configures the TargetAllocator to serve its endpoints over HTTPS. The collectors are configured
+	// to verify the TargetAllocator's certificate and, when a client certificate is set, to present it.
+	// +optional
+	TLS *OpenTelemetryTargetAllocatorTLS `json:"tls,omitempty"`
+
+	// BearerTokenSecret selects the key of a Secret holding the token the collectors have to present
+	// to the TargetAllocator. When not set, no token is required.
+	// +optional
+	BearerTokenSecret *v1.SecretKeySelector `json:"bearerTokenSecret,omitempty"`
 }
 
 type OpenTelemetryTargetAllocatorPrometheusCR struct {
 	// Enabled indicates whether to use a PrometheusOperator custom resources as targets or not.
+	// When enabled, the collectors retrieve all their scrape configs, including the ones generated
+	// from the custom resources, from the TargetAllocator.
 	// +optional
 	Enabled bool `json:"enabled,omitempty"`
+
+	// ServiceMonitorSelector selects the ServiceMonitors to retrieve, based on their labels.
+	// When not set, no ServiceMonitors are selected, and an empty selector selects all of them, like in the Prometheus Operator.
+	// +optional
+	ServiceMonitorSelector *metav1.LabelSelector `json:"serviceMonitorSelector,omitempty"`
+
+	// PodMonitorSelector selects the PodMonitors to retrieve, based on their labels.
+	// When not set, no PodMonitors are selected, and an empty selector selects all of them, like in the Prometheus Operator.
+	// +optional
+	PodMonitorSelector *metav1.LabelSelector `json:"podMonitorSelector,omitempty"`
+
+	// ProbeSelector selects the Probes to retrieve, based on their labels.
+	// When not set, no Probes are selected, and an empty selector selects all of them, like in the Prometheus Operator.
+	// +optional
+	ProbeSelector *metav1.LabelSelector `json:"probeSelector,omitempty"`
+
+	// NamespaceSelector selects the namespaces the ServiceMonitors, PodMonitors and Probes are retrieved from,
+	// based on their labels. When not set, all namespaces are selected. Selecting namespaces requires
+	// the TargetAllocator's ServiceAccount to be allowed to list and watch namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// OpenTelemetryTargetAllocatorTLS defines the certificates used between the collectors and the TargetAllocator.
+type OpenTelemetryTargetAllocatorTLS struct {
+	// SecretName is the name of a Secret of type kubernetes.io/tls in the instance's namespace, holding
+	// the TargetAllocator's certificate (tls.crt), its key (tls.key) and the CA certificate (ca.crt) it is signed with.
+	SecretName string `json:"secretName"`
+
+	// ClientCertSecretName is the name of a Secret of type kubernetes.io/tls in the instance's namespace, holding
+	// the certificate (tls.crt) and key (tls.key) the collectors present to the TargetAllocator. When set, the
+	// TargetAllocator only accepts clients with a certificate signed by the CA certificate of SecretName.
+	// +optional
+	ClientCertSecretName string `json:"clientCertSecretName,omitempty"`
 }
 
 // ScaleSubresourceStatus defines the observed state of the OpenTelemetryCollector's
@@ -179,6 +271,15 @@ type OpenTelemetryCollectorStatus struct {
 	// +listType=atomic
 	// Deprecated: use Kubernetes events instead.
 	Messages []string `json:"messages,omitempty"`
+
+	// Conditions represent the latest observations of the collector's state: Ready, Progressing, Degraded,
+	// ConfigValid and, when the target allocator is enabled, TargetAllocatorReady.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
@@ -187,6 +288,7 @@ type OpenTelemetryCollectorStatus struct {
 // +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.scale.replicas,selectorpath=.status.scale.selector
 // +kubebuilder:printcolumn:name="Mode",type="string",JSONPath=".spec.mode",description="Deployment Mode"
 // +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version",description="OpenTelemetry Version"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the collector is ready"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +operator-sdk:csv:customresourcedefinitions:displayName="OpenTelemetry Collector"
 // This annotation provides a hint for OLM which resources are managed by OpenTelemetryCollector kind.
@@ -16,12 +16,14 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
 	ta "github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator/adapters"
 )
 
@@ -62,6 +64,16 @@ func (r *OpenTelemetryCollector) Default() {
 		one := int32(1)
 		r.Spec.Replicas = &one
 	}
+
+	// a collector per node scrapes the targets of its node
+	if r.Spec.TargetAllocator.Enabled && r.Spec.Mode == ModeDaemonSet && len(r.Spec.TargetAllocator.AllocationStrategy) == 0 {
+		r.Spec.TargetAllocator.AllocationStrategy = OpenTelemetryTargetAllocatorAllocationStrategyPerNode
+	}
+
+	// the replicas of the TargetAllocator only agree on the allocation with a deterministic strategy
+	if r.Spec.TargetAllocator.Replicas != nil && *r.Spec.TargetAllocator.Replicas > 1 && len(r.Spec.TargetAllocator.AllocationStrategy) == 0 {
+		r.Spec.TargetAllocator.AllocationStrategy = OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing
+	}
 }
 
 // +kubebuilder:webhook:verbs=create;update,path=/validate-opentelemetry-io-v1alpha1-opentelemetrycollector,mutating=false,failurePolicy=fail,groups=opentelemetry.io,resources=opentelemetrycollectors,versions=v1alpha1,name=vopentelemetrycollectorcreateupdate.kb.io,sideEffects=none,admissionReviewVersions=v1
@@ -72,13 +84,24 @@ var _ webhook.Validator = &OpenTelemetryCollector{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (r *OpenTelemetryCollector) ValidateCreate() error {
 	opentelemetrycollectorlog.Info("validate create", "name", r.Name)
-	return r.validateCRDSpec()
+	if err := r.validateCRDSpec(); err != nil {
+		return err
+	}
+	return validateConfig(r.Spec.Config)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (r *OpenTelemetryCollector) ValidateUpdate(old runtime.Object) error {
 	opentelemetrycollectorlog.Info("validate update", "name", r.Name)
-	return r.validateCRDSpec()
+	if err := r.validateCRDSpec(); err != nil {
+		return err
+	}
+	// the instances accepted before the configuration was validated can still be updated, as long as their
+	// configuration is left untouched
+	if previous, ok := old.(*OpenTelemetryCollector); ok && previous.Spec.Config == r.Spec.Config {
+		return nil
+	}
+	return validateConfig(r.Spec.Config)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
@@ -99,16 +122,51 @@ func (r *OpenTelemetryCollector) validateCRDSpec() error {
 	}
 
 	// validate target allocation
-	if r.Spec.TargetAllocator.Enabled && r.Spec.Mode != ModeStatefulSet {
+	if r.Spec.TargetAllocator.Enabled && r.Spec.Mode != ModeStatefulSet && r.Spec.Mode != ModeDaemonSet {
 		return fmt.Errorf("the OpenTelemetry Collector mode is set to %s, which does not support the target allocation deployment", r.Spec.Mode)
 	}
+	if r.Spec.TargetAllocator.AllocationStrategy == OpenTelemetryTargetAllocatorAllocationStrategyPerNode && r.Spec.Mode != ModeDaemonSet {
+		return fmt.Errorf("the OpenTelemetry Collector mode is set to %s, which does not support the %s allocation strategy", r.Spec.Mode, OpenTelemetryTargetAllocatorAllocationStrategyPerNode)
+	}
+
+	// validate the exposure of the receivers
+	if r.Spec.Ingress.Type != "" {
+		if r.Spec.Mode == ModeSidecar {
+			return fmt.Errorf("the OpenTelemetry Collector mode is set to %s, which does not support the attribute 'ingress'", r.Spec.Mode)
+		}
+		if len(r.Spec.Ingress.Hostname) == 0 {
+			return fmt.Errorf("the OpenTelemetry Spec Ingress configuration is incorrect, hostname must be set")
+		}
+	}
 
 	// validate Prometheus config for target allocation
 	if r.Spec.TargetAllocator.Enabled {
+		// every prometheus receiver takes part, their job names must be unique
 		_, err := ta.ConfigToPromConfig(r.Spec.Config)
 		if err != nil {
 			return fmt.Errorf("the OpenTelemetry Spec Prometheus configuration is incorrect, %s", err)
 		}
+		if r.Spec.TargetAllocator.PrometheusCR.Enabled {
+			promConfigs, err := ta.ConfigToPromConfigs(r.Spec.Config)
+			if err != nil {
+				return fmt.Errorf("the OpenTelemetry Spec Prometheus configuration is incorrect, %s", err)
+			}
+			if len(promConfigs) > 1 {
+				return fmt.Errorf("the OpenTelemetry Spec Prometheus configuration is incorrect, %s", ta.ErrPrometheusCRMultipleReceivers)
+			}
+		}
+	}
+
+	// validate the replicas of the TargetAllocator
+	if r.Spec.TargetAllocator.Replicas != nil && *r.Spec.TargetAllocator.Replicas > 1 &&
+		r.Spec.TargetAllocator.AllocationStrategy != OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing &&
+		r.Spec.TargetAllocator.AllocationStrategy != OpenTelemetryTargetAllocatorAllocationStrategyPerNode {
+		return fmt.Errorf("the OpenTelemetry Spec TargetAllocator configuration is incorrect, more than one replica requires the %s allocation strategy, or %s in daemonset mode", OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing, OpenTelemetryTargetAllocatorAllocationStrategyPerNode)
+	}
+
+	// validate the TargetAllocator's certificates
+	if tls := r.Spec.TargetAllocator.TLS; tls != nil && len(tls.SecretName) == 0 {
+		return fmt.Errorf("the OpenTelemetry Spec TargetAllocator TLS configuration is incorrect, secretName must be set")
 	}
 
 	// validate autoscale with horizontal pod autoscaler
@@ -124,3 +182,20 @@ func (r *OpenTelemetryCollector) validateCRDSpec() error {
 
 	return nil
 }
+
+// validateConfig reports every error of the collector's configuration which would make the collector fail to start.
+func validateConfig(config string) error {
+	cfg, err := adapters.ConfigFromString(config)
+	if err != nil {
+		return fmt.Errorf("the OpenTelemetry Spec configuration is incorrect, %s", err)
+	}
+	configErrs := adapters.ValidateConfig(opentelemetrycollectorlog, cfg)
+	if len(configErrs) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(configErrs))
+	for _, configErr := range configErrs {
+		messages = append(messages, configErr.Error())
+	}
+	return fmt.Errorf("the OpenTelemetry Spec configuration is incorrect, %s", strings.Join(messages, "; "))
+}
@@ -15,15 +15,18 @@
 package v1alpha1
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestOTELColDefaultingWebhook(t *testing.T) {
 	one := int32(1)
 	five := int32(5)
+	three := int32(3)
 	tests := []struct {
 		name     string
 		otelcol  OpenTelemetryCollector
@@ -67,6 +70,59 @@ func TestOTELColDefaultingWebhook(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "target allocator with multiple replicas",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Replicas: &three,
+					},
+				},
+			},
+			expected: OpenTelemetryCollector{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "opentelemetry-operator",
+					},
+				},
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeDeployment,
+					Replicas:        &one,
+					UpgradeStrategy: UpgradeStrategyAutomatic,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Replicas:           &three,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing,
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator in daemonset mode",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode: ModeDaemonSet,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Enabled: true,
+					},
+				},
+			},
+			expected: OpenTelemetryCollector{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "opentelemetry-operator",
+					},
+				},
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeDaemonSet,
+					Replicas:        &one,
+					UpgradeStrategy: UpgradeStrategyAutomatic,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Enabled:            true,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyPerNode,
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -76,3 +132,256 @@ func TestOTELColDefaultingWebhook(t *testing.T) {
 		})
 	}
 }
+
+const multiplePrometheusReceivers = `receivers:
+  prometheus/infra:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+  prometheus/apps:
+    config:
+      scrape_configs:
+        - job_name: service-x
+`
+
+func TestOTELColValidatingWebhook(t *testing.T) {
+	three := int32(3)
+	tests := []struct {
+		name    string
+		otelcol OpenTelemetryCollector
+		err     string
+	}{
+		{
+			name: "target allocator TLS without a secret",
+			err:  "secretName must be set",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						TLS: &OpenTelemetryTargetAllocatorTLS{ClientCertSecretName: "collector-cert"},
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator replicas with the least-weighted strategy",
+			err:  "more than one replica requires the consistent-hashing allocation strategy",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Replicas:           &three,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyLeastWeighted,
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator replicas with the consistent-hashing strategy",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Replicas:           &three,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing,
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator in deployment mode",
+			err:  "does not support the target allocation deployment",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeDeployment,
+					TargetAllocator: OpenTelemetryTargetAllocator{Enabled: true},
+				},
+			},
+		},
+		{
+			name: "target allocator per-node strategy in statefulset mode",
+			err:  "does not support the per-node allocation strategy",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode: ModeStatefulSet,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Enabled:            true,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyPerNode,
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator replicas with the per-node strategy in daemonset mode",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode: ModeDaemonSet,
+					Config: `receivers:
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+`,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Enabled:            true,
+						Replicas:           &three,
+						AllocationStrategy: OpenTelemetryTargetAllocatorAllocationStrategyPerNode,
+					},
+				},
+			},
+		},
+		{
+			name: "target allocator with several prometheus receivers",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeStatefulSet,
+					Config:          multiplePrometheusReceivers,
+					TargetAllocator: OpenTelemetryTargetAllocator{Enabled: true},
+				},
+			},
+		},
+		{
+			name: "target allocator with a job in several prometheus receivers",
+			err:  `the job "kubelet" is configured by both the prometheus/apps and prometheus/infra receivers`,
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeStatefulSet,
+					Config:          strings.Replace(multiplePrometheusReceivers, "service-x", "kubelet", 1),
+					TargetAllocator: OpenTelemetryTargetAllocator{Enabled: true},
+				},
+			},
+		},
+		{
+			name: "target allocator with conflicting global sections",
+			err:  "the global section of the prometheus/infra receiver differs from the one of the prometheus/apps receiver",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:            ModeStatefulSet,
+					Config:          strings.Replace(multiplePrometheusReceivers, "  prometheus/apps:\n    config:\n", "  prometheus/apps:\n    config:\n      global:\n        external_labels:\n          cluster: a\n", 1),
+					TargetAllocator: OpenTelemetryTargetAllocator{Enabled: true},
+				},
+			},
+		},
+		{
+			name: "target allocator with several prometheus receivers and the Prometheus CRs",
+			err:  "only one can be configured when they are enabled",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:   ModeStatefulSet,
+					Config: multiplePrometheusReceivers,
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						Enabled:      true,
+						PrometheusCR: OpenTelemetryTargetAllocatorPrometheusCR{Enabled: true},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid config",
+			err:  "the OpenTelemetry Spec configuration is incorrect, couldn't parse the opentelemetry-collector configuration",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Config: "receivers: [",
+				},
+			},
+		},
+		{
+			name: "pipeline with an undefined exporter",
+			err:  `service.pipelines.traces.exporters[0]: references the exporter "otlp/typo", which isn't defined in the exporters section`,
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Config: `receivers:
+  otlp:
+    protocols:
+      grpc:
+exporters:
+  otlp:
+    endpoint: backend:4317
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      exporters: [otlp/typo]
+`,
+				},
+			},
+		},
+		{
+			name: "ingress in sidecar mode",
+			err:  "does not support the attribute 'ingress'",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:    ModeSidecar,
+					Ingress: Ingress{Type: IngressTypeIngress, Hostname: "example.com"},
+				},
+			},
+		},
+		{
+			name: "ingress without a hostname",
+			err:  "hostname must be set",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:    ModeDeployment,
+					Ingress: Ingress{Type: IngressTypeIngress},
+				},
+			},
+		},
+		{
+			name: "ingress with a hostname",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					Mode:    ModeDeployment,
+					Ingress: Ingress{Type: IngressTypeIngress, Hostname: "example.com"},
+				},
+			},
+		},
+		{
+			name: "target allocator TLS with a secret",
+			otelcol: OpenTelemetryCollector{
+				Spec: OpenTelemetryCollectorSpec{
+					TargetAllocator: OpenTelemetryTargetAllocator{
+						TLS: &OpenTelemetryTargetAllocatorTLS{SecretName: "ta-cert"},
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.err == "" {
+				assert.Nil(t, test.otelcol.ValidateCreate())
+				assert.Nil(t, test.otelcol.ValidateUpdate(nil))
+			} else {
+				err := test.otelcol.ValidateCreate()
+				assert.Contains(t, err.Error(), test.err)
+				err = test.otelcol.ValidateUpdate(nil)
+				assert.Contains(t, err.Error(), test.err)
+			}
+		})
+	}
+}
+
+func TestOTELColValidatingWebhookUpdate(t *testing.T) {
+	invalid := `receivers:
+  otlp:
+    protocols:
+      grpc:
+exporters:
+  logging:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [logging]
+`
+	old := &OpenTelemetryCollector{Spec: OpenTelemetryCollectorSpec{Config: invalid}}
+
+	// an instance accepted before its configuration was validated can still be updated
+	unchanged := old.DeepCopy()
+	unchanged.Spec.Image = "otel/opentelemetry-collector:0.54.0"
+	assert.NoError(t, unchanged.ValidateUpdate(old))
+
+	changed := old.DeepCopy()
+	changed.Spec.Config = strings.Replace(invalid, "exporters: [logging]", "exporters: [otlp]", 1)
+	err := changed.ValidateUpdate(old)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `references the processor "batch", which isn't defined in the processors section`)
+}
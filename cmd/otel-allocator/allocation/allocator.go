@@ -2,13 +2,46 @@ package allocation
 
 import (
 	"fmt"
+	"math"
 	"net/url"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 )
 
+var (
+	collectorsAllocatable = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_collectors_allocatable",
+		Help: "Number of collectors the allocator is able to allocate to.",
+	})
+	targetsPerCollector = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_targets_per_collector",
+		Help: "The number of targets for each collector.",
+	}, []string{"collector_name"})
+	targetsPerJob = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_targets",
+		Help: "Number of targets allocated for each job.",
+	}, []string{"job_name"})
+	timeToAllocate = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "opentelemetry_allocator_time_to_allocate_seconds",
+		Help: "The time it takes to allocate the targets, in seconds.",
+	}, []string{"call"})
+	reallocations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_reallocations_total",
+		Help: "Number of times the targets were reallocated because the set of collectors changed.",
+	})
+	targetsUnassigned = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_targets_unassigned",
+		Help: "Number of targets no collector can be assigned to, which aren't scraped.",
+	})
+)
+
 /*
 	Load balancer will serve on an HTTP server exposing /jobs/<job_id>/targets <- these are configured using least connection
 	Load balancer will need information about the collectors in order to set the URLs
@@ -21,6 +54,15 @@ type TargetItem struct {
 	TargetURL string
 	Label     model.LabelSet
 	Collector *collector
+	// ScrapeInterval is the scrape interval of the target's job, zero if unknown
+	ScrapeInterval time.Duration
+	// Cost is the share of the work of its collector the target is responsible for, set by the Allocator
+	Cost float64
+	// NodeName is the node the target runs on, if the service discovery knows it
+	NodeName string
+	// AssignedAt is when the target was assigned to its collector, and AssignmentReason why, set by the Allocator
+	AssignedAt       time.Time
+	AssignmentReason string
 }
 
 // Create a struct that holds collector - and jobs for that collector
@@ -29,39 +71,75 @@ type TargetItem struct {
 type collector struct {
 	Name       string
 	NumTargets int
+	// Cost is the sum of the costs of the collector's targets
+	Cost float64
+	// Weight is the share of the targets the collector should receive, relative to the other collectors
+	Weight float64
+	// NodeName is the node the collector runs on
+	NodeName string
+}
+
+// MaxWeight is the highest weight a collector can have, higher weights are capped to it.
+// It bounds the size of the hash ring of the consistent-hashing strategy.
+const MaxWeight = 100
+
+// CollectorInfo describes a collector to the Allocator.
+type CollectorInfo struct {
+	// Weight is the share of the targets the collector should receive, relative to the other collectors
+	Weight float64
+	// NodeName is the node the collector runs on, used by the per-node strategy
+	NodeName string
+}
+
+// weight returns the weight of the collector, collectors without a valid weight count as one
+// and the weight is capped to MaxWeight.
+func (c *collector) weight() float64 {
+	if c.Weight <= 0 || math.IsNaN(c.Weight) || math.IsInf(c.Weight, 0) {
+		return 1
+	}
+	return math.Min(c.Weight, MaxWeight)
+}
+
+// load returns the given cost of targets relative to the collector's weight.
+func (c *collector) load(cost float64) float64 {
+	return cost / c.weight()
 }
 
 // Allocator makes decisions to distribute work among
-// a number of OpenTelemetry collectors based on the number of targets.
+// a number of OpenTelemetry collectors based on the configured AllocationStrategy.
 // Users need to call SetTargets when they have new targets in their
 // clusters and call Reshard to process the new targets and reshard.
 type Allocator struct {
 	m sync.Mutex
 
+	strategy AllocationStrategy
+
 	targetsWaiting map[string]TargetItem // temp buffer to keep targets that are waiting to be processed
 
 	collectors map[string]*collector // all current collectors
 
-	TargetItems map[string]*TargetItem
+	targetItems map[string]*TargetItem
 
-	log logr.Logger
-}
+	// unassigned holds the keys of the waiting targets the strategy found no collector for
+	unassigned map[string]bool
 
-// findNextCollector finds the next collector with less number of targets.
-func (allocator *Allocator) findNextCollector() *collector {
-	var col *collector
-	for _, v := range allocator.collectors {
-		// If the initial collector is empty, set the initial collector to the first element of map
-		if col == nil {
-			col = v
-		} else {
-			if v.NumTargets < col.NumTargets {
-				col = v
-			}
-		}
+	// series holds the number of series reported by the collectors, indexed by target key
+	series map[string]int
+	// seriesTotal is the sum of the reported series, for the average of the targets without a report
+	seriesTotal int
 
-	}
-	return col
+	// snapshot holds the *Snapshot published after the last allocation round
+	snapshot atomic.Value
+	version  uint64
+
+	// rebalanceLimit is the maximum number of targets moved between remaining collectors on a reallocation
+	rebalanceLimit int
+
+	// history holds the most recent assignments, oldest first, up to historySize of them
+	history     []Assignment
+	historySize int
+
+	log logr.Logger
 }
 
 // SetTargets accepts the a list of targets that will be used to make
@@ -79,76 +157,267 @@ func (allocator *Allocator) SetWaitingTargets(targets []TargetItem) {
 }
 
 // SetCollectors sets the set of collectors with key=collectorName, value=Collector object.
-// SetCollectors is called when Collectors are added or removed
+// SetCollectors is called when Collectors are added or removed. Collectors which are part of
+// both the old and the new set keep their targets, new collectors start without any targets.
 func (allocator *Allocator) SetCollectors(collectors []string) {
+	weighted := make(map[string]float64, len(collectors))
+	for _, name := range collectors {
+		weighted[name] = 1
+	}
+	allocator.SetWeightedCollectors(weighted)
+}
+
+// SetWeightedCollectors sets the set of collectors, with key=collectorName, value=weight of the collector.
+// The targets are distributed in proportion to the weights, as far as the allocation strategy allows it.
+func (allocator *Allocator) SetWeightedCollectors(collectors map[string]float64) {
+	infos := make(map[string]CollectorInfo, len(collectors))
+	for name, weight := range collectors {
+		infos[name] = CollectorInfo{Weight: weight}
+	}
+	allocator.SetCollectorInfo(infos)
+}
+
+// SetCollectorInfo sets the set of collectors, with key=collectorName, value=the collector's weight and node.
+func (allocator *Allocator) SetCollectorInfo(collectors map[string]CollectorInfo) {
 	log := allocator.log.WithValues("component", "opentelemetry-targetallocator")
 
 	allocator.m.Lock()
 	defer allocator.m.Unlock()
 	if len(collectors) == 0 {
-		log.Info("No collector instances present")
-		return
-	}
-	for k := range allocator.collectors {
-		delete(allocator.collectors, k)
+		// the targets are unassigned by the next reallocation rather than kept on collectors which can't scrape them
+		log.Info("No collector instances present, the targets are unassigned until there are")
 	}
 
-	for _, i := range collectors {
-		allocator.collectors[i] = &collector{Name: i, NumTargets: 0}
+	current := make(map[string]*collector, len(collectors))
+	for name, info := range collectors {
+		if col, ok := allocator.collectors[name]; ok {
+			col.Weight = info.Weight
+			col.NodeName = info.NodeName
+			current[name] = col
+		} else {
+			current[name] = &collector{Name: name, NumTargets: 0, Weight: info.Weight, NodeName: info.NodeName}
+		}
 	}
+	for k := range allocator.collectors {
+		if _, ok := current[k]; !ok {
+			log.V(2).Info("collector removed", "collector", k)
+		}
+	}
+	allocator.collectors = current
+	allocator.strategy.SetCollectors(allocator.collectors)
+	collectorsAllocatable.Set(float64(len(collectors)))
 }
 
 // Reallocate needs to be called to process the new target updates.
 // Until Reallocate is called, old targets will be served.
 func (allocator *Allocator) AllocateTargets() {
 	allocator.m.Lock()
+	timer := prometheus.NewTimer(timeToAllocate.WithLabelValues("AllocateTargets"))
+	defer timer.ObserveDuration()
 	defer allocator.m.Unlock()
 	allocator.removeOutdatedTargets()
 	allocator.processWaitingTargets()
+	allocator.updateUnassignedTargets()
+	allocator.recordTargetMetrics()
+	allocator.publishSnapshot()
 }
 
-// ReallocateCollectors reallocates the targets among the new collector instances
+// ReallocateCollectors reallocates the targets among the new collector instances.
+// Only the targets owned by collectors which are gone get a new collector, plus the ones
+// the strategy decides to rebalance.
 func (allocator *Allocator) ReallocateCollectors() {
 	allocator.m.Lock()
+	timer := prometheus.NewTimer(timeToAllocate.WithLabelValues("ReallocateCollectors"))
+	defer timer.ObserveDuration()
 	defer allocator.m.Unlock()
-	allocator.TargetItems = make(map[string]*TargetItem)
+	allocator.reassignOrphanedTargets()
+	for k, col := range allocator.strategy.Rebalance(allocator.targetItems, allocator.collectors, allocator.rebalanceLimit) {
+		allocator.moveTarget(k, col, ReasonRebalanced)
+	}
 	allocator.processWaitingTargets()
+	allocator.updateUnassignedTargets()
+	allocator.recordTargetMetrics()
+	allocator.publishSnapshot()
+	reallocations.Inc()
 }
 
 // removeOutdatedTargets removes targets that are no longer available.
 func (allocator *Allocator) removeOutdatedTargets() {
-	for k := range allocator.TargetItems {
+	for k, item := range allocator.targetItems {
 		if _, ok := allocator.targetsWaiting[k]; !ok {
-			allocator.collectors[allocator.TargetItems[k].Collector.Name].NumTargets--
-			delete(allocator.TargetItems, k)
+			item.Collector.NumTargets--
+			item.Collector.Cost -= item.Cost
+			delete(allocator.targetItems, k)
+			allocator.deleteSeries(k)
 		}
 	}
 }
 
+// reassignOrphanedTargets moves the targets of collectors which are no longer present to one of the current collectors.
+func (allocator *Allocator) reassignOrphanedTargets() {
+	// sort the keys so that the least-weighted strategy makes the same choices for the same input
+	var orphaned []string
+	for k, item := range allocator.targetItems {
+		if _, ok := allocator.collectors[item.Collector.Name]; !ok {
+			orphaned = append(orphaned, k)
+		}
+	}
+	sort.Strings(orphaned)
+
+	for _, k := range orphaned {
+		col := allocator.strategy.Next(k, allocator.targetItems[k], allocator.collectors)
+		if col == nil {
+			// will be picked up again by processWaitingTargets once there's a collector
+			delete(allocator.targetItems, k)
+			continue
+		}
+		allocator.moveTarget(k, col, ReasonCollectorRemoved)
+	}
+}
+
+// moveTarget assigns the target with the given key to another collector for the given reason.
+// The target item is replaced instead of being updated in place, as it might still be referenced by readers.
+func (allocator *Allocator) moveTarget(key string, col *collector, reason string) {
+	item, ok := allocator.targetItems[key]
+	if !ok || item.Collector == col {
+		return
+	}
+	if current, ok := allocator.collectors[item.Collector.Name]; ok && current == item.Collector {
+		current.NumTargets--
+		current.Cost -= item.Cost
+	}
+	moved := *item
+	moved.Collector = col
+	moved.AssignedAt = time.Now()
+	moved.AssignmentReason = reason
+	col.NumTargets++
+	col.Cost += moved.Cost
+	allocator.targetItems[key] = &moved
+	allocator.recordAssignment(Assignment{
+		Time:    moved.AssignedAt,
+		JobName: moved.JobName,
+		Target:  moved.TargetURL,
+		From:    item.Collector.Name,
+		To:      col.Name,
+		Reason:  reason,
+	})
+}
+
 // processWaitingTargets processes the newly set targets.
 func (allocator *Allocator) processWaitingTargets() {
 	for k, v := range allocator.targetsWaiting {
-		if _, ok := allocator.TargetItems[k]; !ok {
-			col := allocator.findNextCollector()
-			allocator.TargetItems[k] = &v
+		if _, ok := allocator.targetItems[k]; !ok {
 			targetItem := TargetItem{
 				JobName:   v.JobName,
 				Link:      LinkJSON{fmt.Sprintf("/jobs/%s/targets", url.QueryEscape(v.JobName))},
 				TargetURL: v.TargetURL,
 				Label:     v.Label,
-				Collector: col,
+
+				ScrapeInterval: v.ScrapeInterval,
+				NodeName:       v.NodeName,
+				Cost:           allocator.cost(k, v),
+			}
+			col := allocator.strategy.Next(k, &targetItem, allocator.collectors)
+			if col == nil {
+				// no collector available yet, the target will be picked up by the next reallocation
+				continue
 			}
+			targetItem.Collector = col
+			targetItem.AssignedAt = time.Now()
+			targetItem.AssignmentReason = ReasonNewTarget
 			col.NumTargets++
-			allocator.TargetItems[v.JobName+v.TargetURL] = &targetItem
+			col.Cost += targetItem.Cost
+			allocator.targetItems[v.JobName+v.TargetURL] = &targetItem
+			allocator.recordAssignment(Assignment{
+				Time:    targetItem.AssignedAt,
+				JobName: targetItem.JobName,
+				Target:  targetItem.TargetURL,
+				To:      col.Name,
+				Reason:  ReasonNewTarget,
+			})
 		}
 	}
 }
 
-func NewAllocator(log logr.Logger) *Allocator {
-	return &Allocator{
+// updateUnassignedTargets finds the waiting targets which didn't get a collector, e.g. the targets without a node or
+// on a node without a collector with the per-node strategy, and warns about the ones which weren't unassigned before.
+func (allocator *Allocator) updateUnassignedTargets() {
+	unassigned := make(map[string]bool)
+	for k, item := range allocator.targetsWaiting {
+		if _, ok := allocator.targetItems[k]; ok {
+			continue
+		}
+		unassigned[k] = true
+		if !allocator.unassigned[k] {
+			allocator.log.Info("No collector can scrape the target, it's left unassigned", "job", item.JobName, "target", item.TargetURL, "node", item.NodeName, "strategy", allocator.strategy.Name())
+		}
+	}
+	allocator.unassigned = unassigned
+}
+
+// Snapshot returns the allocation published after the last allocation round. It's safe to call
+// concurrently with any other method of the Allocator.
+func (allocator *Allocator) Snapshot() *Snapshot {
+	return allocator.snapshot.Load().(*Snapshot)
+}
+
+// publishSnapshot makes the current allocation visible to readers. It must be called with the lock held.
+func (allocator *Allocator) publishSnapshot() {
+	allocator.version++
+	unassigned := make([]TargetItem, 0, len(allocator.unassigned))
+	for k := range allocator.unassigned {
+		unassigned = append(unassigned, allocator.targetsWaiting[k])
+	}
+	allocator.snapshot.Store(newSnapshot(allocator.version, allocator.targetItems, allocator.collectors, allocator.history, unassigned))
+}
+
+// Strategy returns the name of the allocation strategy in use.
+func (allocator *Allocator) Strategy() string {
+	return allocator.strategy.Name()
+}
+
+// recordTargetMetrics publishes the current distribution of targets per collector and per job.
+func (allocator *Allocator) recordTargetMetrics() {
+	targetsPerCollector.Reset()
+	costPerCollector.Reset()
+	for _, col := range allocator.collectors {
+		targetsPerCollector.WithLabelValues(col.Name).Set(float64(col.NumTargets))
+		costPerCollector.WithLabelValues(col.Name).Set(col.Cost)
+	}
+
+	jobs := make(map[string]int)
+	for _, item := range allocator.targetItems {
+		jobs[item.JobName]++
+	}
+	targetsPerJob.Reset()
+	for job, count := range jobs {
+		targetsPerJob.WithLabelValues(job).Set(float64(count))
+	}
+	targetsUnassigned.Set(float64(len(allocator.unassigned)))
+}
+
+// WithRebalanceLimit sets the maximum number of targets which are moved away from overloaded collectors
+// when the set of collectors changes. Targets of removed collectors are always moved and don't count towards the limit.
+func WithRebalanceLimit(limit int) func(*Allocator) {
+	return func(allocator *Allocator) {
+		allocator.rebalanceLimit = limit
+	}
+}
+
+func NewAllocator(log logr.Logger, strategy AllocationStrategy, options ...func(*Allocator)) *Allocator {
+	allocator := &Allocator{
 		log:            log,
+		strategy:       strategy,
 		targetsWaiting: make(map[string]TargetItem),
 		collectors:     make(map[string]*collector),
-		TargetItems:    make(map[string]*TargetItem),
+		targetItems:    make(map[string]*TargetItem),
+		unassigned:     make(map[string]bool),
+		series:         make(map[string]int),
+		historySize:    DefaultHistorySize,
+	}
+	allocator.publishSnapshot()
+	for _, option := range options {
+		option(allocator)
 	}
+	return allocator
 }
@@ -9,25 +9,10 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// Tests least connection - The expected collector after running findNextCollector should be the collector with the least amount of workload
-func TestFindNextCollector(t *testing.T) {
-	var log logr.Logger
-	s := NewAllocator(log)
-
-	defaultCol := collector{Name: "default-col", NumTargets: 1}
-	maxCol := collector{Name: "max-col", NumTargets: 2}
-	leastCol := collector{Name: "least-col", NumTargets: 0}
-	s.collectors[maxCol.Name] = &maxCol
-	s.collectors[leastCol.Name] = &leastCol
-	s.collectors[defaultCol.Name] = &defaultCol
-
-	assert.Equal(t, "least-col", s.findNextCollector().Name)
-}
+var logger = logr.Discard()
 
 func TestSetCollectors(t *testing.T) {
-
-	var log logr.Logger
-	s := NewAllocator(log)
+	s := NewAllocator(logger, newLeastWeightedStrategy())
 
 	cols := []string{"col-1", "col-2", "col-3"}
 	s.SetCollectors(cols)
@@ -42,8 +27,7 @@ func TestSetCollectors(t *testing.T) {
 
 func TestAddingAndRemovingTargets(t *testing.T) {
 	// prepare allocator with initial targets and collectors
-	var log logr.Logger
-	s := NewAllocator(log)
+	s := NewAllocator(logger, newLeastWeightedStrategy())
 
 	cols := []string{"col-1", "col-2", "col-3"}
 	s.SetCollectors(cols)
@@ -60,7 +44,7 @@ func TestAddingAndRemovingTargets(t *testing.T) {
 
 	// verify
 	expectedTargetLen := len(initTargets)
-	assert.Len(t, s.TargetItems, expectedTargetLen)
+	assert.Len(t, s.targetItems, expectedTargetLen)
 
 	// prepare second round of targets
 	tar := []string{"prometheus:1001", "prometheus:1002", "prometheus:1003", "prometheus:1004"}
@@ -75,11 +59,11 @@ func TestAddingAndRemovingTargets(t *testing.T) {
 
 	// verify
 	expectedNewTargetLen := len(tar)
-	assert.Len(t, s.TargetItems, expectedNewTargetLen)
+	assert.Len(t, s.targetItems, expectedNewTargetLen)
 
 	// verify results map
 	for _, i := range tar {
-		_, ok := s.TargetItems["sample-name"+i]
+		_, ok := s.targetItems["sample-name"+i]
 		assert.True(t, ok)
 	}
 }
@@ -88,8 +72,7 @@ func TestAddingAndRemovingTargets(t *testing.T) {
 func TestCollectorBalanceWhenAddingAndRemovingAtRandom(t *testing.T) {
 
 	// prepare allocator with 3 collectors and 'random' amount of targets
-	var log logr.Logger
-	s := NewAllocator(log)
+	s := NewAllocator(logger, newLeastWeightedStrategy())
 
 	cols := []string{"col-1", "col-2", "col-3"}
 	s.SetCollectors(cols)
@@ -107,8 +90,8 @@ func TestCollectorBalanceWhenAddingAndRemovingAtRandom(t *testing.T) {
 	// Divisor needed to get 15%
 	divisor := 6.7
 
-	count := len(s.TargetItems) / len(s.collectors)
-	percent := float64(len(s.TargetItems)) / divisor
+	count := len(s.targetItems) / len(s.collectors)
+	percent := float64(len(s.targetItems)) / divisor
 
 	// test
 	for _, i := range s.collectors {
@@ -126,8 +109,8 @@ func TestCollectorBalanceWhenAddingAndRemovingAtRandom(t *testing.T) {
 	s.SetWaitingTargets(newTargetList)
 	s.AllocateTargets()
 
-	count = len(s.TargetItems) / len(s.collectors)
-	percent = float64(len(s.TargetItems)) / divisor
+	count = len(s.targetItems) / len(s.collectors)
+	percent = float64(len(s.targetItems)) / divisor
 
 	// test
 	for _, i := range s.collectors {
@@ -144,11 +127,94 @@ func TestCollectorBalanceWhenAddingAndRemovingAtRandom(t *testing.T) {
 	s.SetWaitingTargets(newTargetList)
 	s.AllocateTargets()
 
-	count = len(s.TargetItems) / len(s.collectors)
-	percent = float64(len(s.TargetItems)) / divisor
+	count = len(s.targetItems) / len(s.collectors)
+	percent = float64(len(s.targetItems)) / divisor
 
 	// test
 	for _, i := range s.collectors {
 		assert.InDelta(t, i.NumTargets, count, math.Round(percent))
 	}
 }
+
+// Tests that only the targets of a removed collector are moved to the remaining collectors
+func TestRemovingCollectorOnlyMovesItsTargets(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1", "col-2", "col-3"})
+	s.SetWaitingTargets(makeTargets(30))
+	s.AllocateTargets()
+	before := assignments(s)
+
+	s.SetCollectors([]string{"col-1", "col-3"})
+	s.ReallocateCollectors()
+
+	assert.Len(t, s.targetItems, 30)
+	for k, col := range assignments(s) {
+		if before[k] != "col-2" {
+			assert.Equal(t, before[k], col, k)
+		} else {
+			assert.NotEqual(t, "col-2", col, k)
+		}
+	}
+	assert.Equal(t, 15, s.collectors["col-1"].NumTargets)
+	assert.Equal(t, 15, s.collectors["col-3"].NumTargets)
+}
+
+func TestRemovingAllCollectorsUnassignsTheTargets(t *testing.T) {
+	for _, strategy := range GetRegisteredStrategyNames() {
+		t.Run(strategy, func(t *testing.T) {
+			allocationStrategy, err := NewStrategy(strategy)
+			assert.NoError(t, err)
+			s := NewAllocator(logger, allocationStrategy)
+			s.SetCollectorInfo(map[string]CollectorInfo{"col-1": {NodeName: "node-1"}, "col-2": {NodeName: "node-2"}})
+			s.SetWaitingTargets(makeNodeTargets("node-1", "node-2", "node-1", "node-2"))
+			s.AllocateTargets()
+			assert.Len(t, s.targetItems, 4)
+
+			// e.g. none of the collector pods is ready
+			s.SetCollectorInfo(map[string]CollectorInfo{})
+			s.ReallocateCollectors()
+			assert.Empty(t, s.targetItems)
+			assert.Empty(t, s.Snapshot().Jobs())
+
+			s.SetCollectorInfo(map[string]CollectorInfo{"col-1": {NodeName: "node-1"}, "col-2": {NodeName: "node-2"}})
+			s.ReallocateCollectors()
+			assert.Len(t, s.targetItems, 4)
+			assert.Equal(t, 4, s.collectors["col-1"].NumTargets+s.collectors["col-2"].NumTargets)
+		})
+	}
+}
+
+// Tests that existing targets stay where they are when a collector is added, unless rebalancing is enabled
+func TestAddingCollectorWithRebalanceLimit(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		limit    int
+		expected int
+	}{
+		{name: "no rebalancing", limit: 0, expected: 0},
+		{name: "limited rebalancing", limit: 4, expected: 4},
+		{name: "full rebalancing", limit: 100, expected: 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewAllocator(logger, newLeastWeightedStrategy(), WithRebalanceLimit(tt.limit))
+			s.SetCollectors([]string{"col-1", "col-2", "col-3"})
+			s.SetWaitingTargets(makeTargets(24))
+			s.AllocateTargets()
+			before := assignments(s)
+
+			s.SetCollectors([]string{"col-1", "col-2", "col-3", "col-4"})
+			s.ReallocateCollectors()
+
+			moved := 0
+			for k, col := range assignments(s) {
+				if before[k] != col {
+					moved++
+					assert.Equal(t, "col-4", col, k)
+				}
+			}
+			assert.Equal(t, tt.expected, moved)
+			assert.Equal(t, tt.expected, s.collectors["col-4"].NumTargets)
+			assert.Len(t, s.targetItems, 24)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package allocation
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// virtualNodes is the number of points a collector with a weight of one gets on the hash ring.
+// More points give a more even distribution at the cost of a bigger ring.
+const virtualNodes = 100
+
+var _ AllocationStrategy = &consistentHashingStrategy{}
+
+// consistentHashingStrategy places the collectors on a hash ring and assigns each target
+// to the first collector found clockwise from the target's hash. When a collector joins or leaves,
+// only the targets between it and its predecessors on the ring change owner, which is about 1/N of them.
+type consistentHashingStrategy struct {
+	ring   []uint64
+	owners map[uint64]string
+}
+
+func newConsistentHashingStrategy() AllocationStrategy {
+	return &consistentHashingStrategy{
+		owners: make(map[uint64]string),
+	}
+}
+
+func (s *consistentHashingStrategy) Name() string {
+	return ConsistentHashingStrategy
+}
+
+// SetCollectors rebuilds the hash ring for the given collectors. Each collector gets a number of points
+// proportional to its weight, so that it owns a proportional share of the targets. As the weights are capped
+// to MaxWeight, a collector never gets more than virtualNodes*MaxWeight points.
+func (s *consistentHashingStrategy) SetCollectors(collectors map[string]*collector) {
+	s.ring = make([]uint64, 0, len(collectors)*virtualNodes)
+	s.owners = make(map[uint64]string, len(collectors)*virtualNodes)
+
+	// walk the collectors in a fixed order so that collisions are always resolved the same way
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		nodes := int(math.Round(virtualNodes * collectors[name].weight()))
+		if nodes < 1 {
+			nodes = 1
+		}
+		for i := 0; i < nodes; i++ {
+			h := xxhash.Sum64String(name + "-" + strconv.Itoa(i))
+			if _, ok := s.owners[h]; ok {
+				// hash collision between two virtual nodes, keep the first one
+				continue
+			}
+			s.owners[h] = name
+			s.ring = append(s.ring, h)
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i] < s.ring[j] })
+}
+
+// Next returns the owner of the given key on the hash ring. The placement doesn't depend on the cost,
+// the weights of the collectors are the only way to balance their load.
+func (s *consistentHashingStrategy) Next(key string, _ *TargetItem, collectors map[string]*collector) *collector {
+	if len(s.ring) == 0 {
+		return nil
+	}
+	h := xxhash.Sum64String(key)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i] >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return collectors[s.owners[s.ring[i]]]
+}
+
+// Rebalance moves every target whose owner on the hash ring changed. The limit is ignored, as moving only some
+// of them would make the placement depend on the history of the collectors instead of the current set.
+func (s *consistentHashingStrategy) Rebalance(targets map[string]*TargetItem, collectors map[string]*collector, _ int) map[string]*collector {
+	moves := make(map[string]*collector)
+	for k, item := range targets {
+		if col := s.Next(k, item, collectors); col != nil && col != item.Collector {
+			moves[k] = col
+		}
+	}
+	return moves
+}
@@ -0,0 +1,153 @@
+package allocation
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTargets(n int) []TargetItem {
+	var targets []TargetItem
+	for i := 0; i < n; i++ {
+		targets = append(targets, TargetItem{JobName: "sample-name", TargetURL: fmt.Sprintf("prometheus:%d", 1000+i), Label: model.LabelSet{}})
+	}
+	return targets
+}
+
+func assignments(s *Allocator) map[string]string {
+	result := make(map[string]string, len(s.targetItems))
+	for k, v := range s.targetItems {
+		result[k] = v.Collector.Name
+	}
+	return result
+}
+
+func TestConsistentHashingIsDeterministic(t *testing.T) {
+	first := NewAllocator(logger, newConsistentHashingStrategy())
+	second := NewAllocator(logger, newConsistentHashingStrategy())
+
+	// the order in which collectors are provided must not matter
+	first.SetCollectors([]string{"col-1", "col-2", "col-3"})
+	second.SetCollectors([]string{"col-3", "col-1", "col-2"})
+
+	targets := makeTargets(100)
+	first.SetWaitingTargets(targets)
+	first.AllocateTargets()
+	second.SetWaitingTargets(targets)
+	second.AllocateTargets()
+
+	assert.Equal(t, assignments(first), assignments(second))
+}
+
+func TestConsistentHashingIgnoresHistory(t *testing.T) {
+	// two replicas of the TargetAllocator must agree on the allocation even if they observed
+	// the collectors and targets in a different order
+	first := NewAllocator(logger, newConsistentHashingStrategy())
+	second := NewAllocator(logger, newConsistentHashingStrategy())
+
+	targets := makeTargets(100)
+	first.SetCollectors([]string{"col-1", "col-2", "col-3"})
+	first.SetWaitingTargets(targets)
+	first.AllocateTargets()
+
+	second.SetCollectors([]string{"col-1"})
+	second.SetWaitingTargets(targets[:50])
+	second.AllocateTargets()
+	second.SetCollectors([]string{"col-1", "col-2", "col-3", "col-4"})
+	second.ReallocateCollectors()
+	second.SetWaitingTargets(targets)
+	second.AllocateTargets()
+	second.SetCollectors([]string{"col-3", "col-2", "col-1"})
+	second.ReallocateCollectors()
+
+	assert.Equal(t, assignments(first), assignments(second))
+}
+
+func TestConsistentHashingBalance(t *testing.T) {
+	s := NewAllocator(logger, newConsistentHashingStrategy())
+	cols := []string{"col-1", "col-2", "col-3"}
+	s.SetCollectors(cols)
+
+	targets := makeTargets(3000)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	assert.Len(t, s.targetItems, len(targets))
+	even := len(targets) / len(cols)
+	for _, col := range s.collectors {
+		// virtual nodes keep every collector within 25% of an even distribution
+		assert.InDelta(t, even, col.NumTargets, float64(even)/4, col.Name)
+	}
+}
+
+func TestConsistentHashingMovesFewTargetsOnCollectorChange(t *testing.T) {
+	s := NewAllocator(logger, newConsistentHashingStrategy())
+	s.SetCollectors([]string{"col-1", "col-2", "col-3", "col-4"})
+
+	targets := makeTargets(2000)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+	before := assignments(s)
+
+	// add a collector: only targets moving to the new collector may change owner
+	s.SetCollectors([]string{"col-1", "col-2", "col-3", "col-4", "col-5"})
+	s.ReallocateCollectors()
+	afterAdd := assignments(s)
+
+	moved := 0
+	for k, col := range afterAdd {
+		if before[k] != col {
+			moved++
+			assert.Equal(t, "col-5", col)
+		}
+	}
+	assert.Less(t, moved, len(targets)/3)
+
+	// remove a collector: only its targets may move
+	s.SetCollectors([]string{"col-1", "col-3", "col-4", "col-5"})
+	s.ReallocateCollectors()
+	afterRemove := assignments(s)
+
+	for k, col := range afterRemove {
+		if afterAdd[k] != col {
+			assert.Equal(t, "col-2", afterAdd[k])
+		}
+	}
+}
+
+func TestConsistentHashingWeightedBalance(t *testing.T) {
+	s := NewAllocator(logger, newConsistentHashingStrategy())
+	s.SetWeightedCollectors(map[string]float64{"col-1": 1, "col-2": 1, "col-3": 2})
+
+	targets := makeTargets(4000)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	for _, col := range s.collectors {
+		expected := float64(len(targets)) * col.Weight / 4
+		assert.InDelta(t, expected, col.NumTargets, expected/4, col.Name)
+	}
+}
+
+func TestConsistentHashingInvalidWeights(t *testing.T) {
+	s := newConsistentHashingStrategy().(*consistentHashingStrategy)
+	s.SetCollectors(map[string]*collector{
+		"col-1": {Name: "col-1", Weight: 1e9},
+		"col-2": {Name: "col-2", Weight: math.Inf(1)},
+		"col-3": {Name: "col-3", Weight: math.NaN()},
+		"col-4": {Name: "col-4", Weight: -1},
+	})
+
+	owned := map[string]int{}
+	for _, owner := range s.owners {
+		owned[owner]++
+	}
+	assert.LessOrEqual(t, owned["col-1"], virtualNodes*MaxWeight)
+	assert.LessOrEqual(t, owned["col-2"], virtualNodes)
+	assert.LessOrEqual(t, owned["col-3"], virtualNodes)
+	assert.LessOrEqual(t, owned["col-4"], virtualNodes)
+	assert.Greater(t, owned["col-2"], 0)
+}
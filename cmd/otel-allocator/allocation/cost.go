@@ -0,0 +1,104 @@
+package allocation
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// referenceInterval is the scrape interval at which a target with a single series costs one.
+const referenceInterval = time.Minute
+
+var (
+	costPerCollector = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_cost_per_collector",
+		Help: "The total cost of the targets of each collector.",
+	}, []string{"collector_name"})
+	seriesReports = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_series_reports_total",
+		Help: "Number of series counts reported by the collectors.",
+	})
+	seriesReportsRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_series_reports_rejected_total",
+		Help: "Number of series counts ignored, as their target isn't allocated to the collector reporting them.",
+	})
+)
+
+// SeriesReport is the number of series a collector observed during the last scrape of a target.
+type SeriesReport struct {
+	CollectorID string `json:"collector_id"`
+	JobName     string `json:"job_name"`
+	TargetURL   string `json:"target"`
+	Series      int    `json:"series"`
+}
+
+// cost returns the cost of the given target, which is proportional to the number of samples it produces per minute.
+// Targets without a series count reported by a collector are assumed to have the average number of series
+// of the reported targets, so that they stay comparable to the others.
+func (allocator *Allocator) cost(key string, item TargetItem) float64 {
+	cost := 1.0
+	if item.ScrapeInterval > 0 {
+		cost = float64(referenceInterval) / float64(item.ScrapeInterval)
+	}
+	if series, ok := allocator.series[key]; ok {
+		return cost * float64(series)
+	}
+	if len(allocator.series) > 0 {
+		return cost * float64(allocator.seriesTotal) / float64(len(allocator.series))
+	}
+	return cost
+}
+
+// setSeries records the number of series of the target, keeping the total up to date.
+func (allocator *Allocator) setSeries(key string, series int) {
+	allocator.seriesTotal += series - allocator.series[key]
+	allocator.series[key] = series
+}
+
+// deleteSeries forgets the number of series of the target, keeping the total up to date.
+func (allocator *Allocator) deleteSeries(key string) {
+	allocator.seriesTotal -= allocator.series[key]
+	delete(allocator.series, key)
+}
+
+// ReportSeries records the number of series of the given targets and updates the cost of all targets. Only the
+// collector a target is allocated to can report it: the reports of other collectors, for unknown targets or with
+// invalid counts are ignored, and their number is returned. Targets aren't moved because of their new cost, only the
+// allocation of new targets and the rebalancing take it into account.
+func (allocator *Allocator) ReportSeries(reports []SeriesReport) int {
+	allocator.m.Lock()
+	defer allocator.m.Unlock()
+
+	rejected := 0
+	for _, report := range reports {
+		key := report.JobName + report.TargetURL
+		item, ok := allocator.targetItems[key]
+		if !ok || report.Series <= 0 || item.Collector == nil || item.Collector.Name != report.CollectorID {
+			rejected++
+			seriesReportsRejected.Inc()
+			continue
+		}
+		allocator.setSeries(key, report.Series)
+		seriesReports.Inc()
+	}
+	allocator.updateCosts()
+	allocator.recordTargetMetrics()
+	allocator.publishSnapshot()
+	return rejected
+}
+
+// updateCosts computes the cost of every target and collector again. It must be called with the lock held.
+func (allocator *Allocator) updateCosts() {
+	for _, col := range allocator.collectors {
+		col.Cost = 0
+	}
+	for k, item := range allocator.targetItems {
+		updated := *item
+		updated.Cost = allocator.cost(k, updated)
+		allocator.targetItems[k] = &updated
+		if col, ok := allocator.collectors[item.Collector.Name]; ok && col == item.Collector {
+			col.Cost += updated.Cost
+		}
+	}
+}
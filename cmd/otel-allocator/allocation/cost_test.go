@@ -0,0 +1,76 @@
+package allocation
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeJobTargets(job string, n int, interval time.Duration) []TargetItem {
+	var targets []TargetItem
+	for i := 0; i < n; i++ {
+		targets = append(targets, TargetItem{JobName: job, TargetURL: fmt.Sprintf("%s:%d", job, 1000+i), Label: model.LabelSet{}, ScrapeInterval: interval})
+	}
+	return targets
+}
+
+func TestCostOfScrapeInterval(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+
+	assert.Equal(t, 6.0, s.cost("fast", TargetItem{ScrapeInterval: 10 * time.Second}))
+	assert.Equal(t, 1.0, s.cost("default", TargetItem{ScrapeInterval: time.Minute}))
+	assert.Equal(t, 0.5, s.cost("slow", TargetItem{ScrapeInterval: 2 * time.Minute}))
+	assert.Equal(t, 1.0, s.cost("unknown", TargetItem{}))
+}
+
+func TestLeastWeightedBalancesCost(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1", "col-2"})
+
+	// both jobs cost 36 in total, but one has six times fewer targets
+	targets := append(makeJobTargets("fast", 6, 10*time.Second), makeJobTargets("slow", 36, time.Minute)...)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	for _, col := range s.collectors {
+		// a collector can't be off by more than the cost of the most expensive target
+		assert.InDelta(t, 36, col.Cost, 6, col.Name)
+	}
+}
+
+func TestReportSeries(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1"})
+	s.SetWaitingTargets(makeJobTargets("job", 3, 30*time.Second))
+	s.AllocateTargets()
+	assert.Equal(t, 6.0, s.collectors["col-1"].Cost)
+
+	rejected := s.ReportSeries([]SeriesReport{
+		{CollectorID: "col-1", JobName: "job", TargetURL: "job:1000", Series: 100},
+		{CollectorID: "col-1", JobName: "job", TargetURL: "job:1001", Series: 300},
+		// unknown targets, invalid counts and targets of other collectors are ignored
+		{CollectorID: "col-1", JobName: "job", TargetURL: "job:9999", Series: 50},
+		{CollectorID: "col-1", JobName: "job", TargetURL: "job:1002", Series: -1},
+		{CollectorID: "col-2", JobName: "job", TargetURL: "job:1002", Series: 5000},
+		{JobName: "job", TargetURL: "job:1002", Series: 5000},
+	})
+	assert.Equal(t, 4, rejected)
+
+	assert.Equal(t, 200.0, s.targetItems["jobjob:1000"].Cost)
+	assert.Equal(t, 600.0, s.targetItems["jobjob:1001"].Cost)
+	// the target without a report is assumed to have the average number of series
+	assert.Equal(t, 400.0, s.targetItems["jobjob:1002"].Cost)
+	assert.Equal(t, 1200.0, s.collectors["col-1"].Cost)
+	assert.Equal(t, 1200.0, s.Snapshot().collectors["col-1"].Cost)
+
+	// the series count of removed targets is forgotten
+	s.SetWaitingTargets(makeJobTargets("job", 1, 30*time.Second))
+	s.AllocateTargets()
+	assert.Len(t, s.series, 1)
+	assert.Equal(t, 100, s.seriesTotal)
+	assert.True(t, math.Abs(s.collectors["col-1"].Cost-200) < 1e-9)
+}
@@ -0,0 +1,49 @@
+package allocation
+
+import "time"
+
+const (
+	// ReasonNewTarget is the reason of the assignment of a target which wasn't allocated before.
+	ReasonNewTarget = "new target"
+
+	// ReasonCollectorRemoved is the reason of the assignment of a target whose collector is gone.
+	ReasonCollectorRemoved = "collector removed"
+
+	// ReasonRebalanced is the reason of the assignment of a target moved by the allocation strategy
+	// from a remaining collector to another one.
+	ReasonRebalanced = "rebalanced"
+
+	// DefaultHistorySize is the number of assignments kept in the history when no size is configured.
+	DefaultHistorySize = 100
+)
+
+// Assignment records a target being assigned to a collector.
+type Assignment struct {
+	Time    time.Time `json:"time"`
+	JobName string    `json:"job_name"`
+	Target  string    `json:"target"`
+	// From is the collector which had the target before, empty for new targets
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// WithHistorySize sets the number of recent assignments kept by the Allocator, zero disables the history.
+func WithHistorySize(size int) func(*Allocator) {
+	return func(allocator *Allocator) {
+		allocator.historySize = size
+	}
+}
+
+// recordAssignment adds the assignment to the history, dropping the oldest ones beyond its size.
+// It must be called with the lock held.
+func (allocator *Allocator) recordAssignment(assignment Assignment) {
+	if allocator.historySize <= 0 {
+		return
+	}
+	allocator.history = append(allocator.history, assignment)
+	if excess := len(allocator.history) - allocator.historySize; excess > 0 {
+		// copy rather than reslice, so that the dropped assignments don't keep the backing array growing
+		allocator.history = append(allocator.history[:0], allocator.history[excess:]...)
+	}
+}
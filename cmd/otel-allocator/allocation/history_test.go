@@ -0,0 +1,90 @@
+package allocation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignmentReasons(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy(), WithRebalanceLimit(10))
+	s.SetCollectors([]string{"col-1", "col-2"})
+	s.SetWaitingTargets(makeTargets(4))
+	s.AllocateTargets()
+
+	for _, item := range s.Snapshot().TargetItems() {
+		assert.Equal(t, ReasonNewTarget, item.AssignmentReason)
+		assert.False(t, item.AssignedAt.IsZero())
+	}
+	assert.Len(t, s.Snapshot().History(), 4)
+
+	before := assignments(s)
+	s.SetCollectors([]string{"col-1"})
+	s.ReallocateCollectors()
+	for k, item := range s.Snapshot().TargetItems() {
+		if before[k] == "col-2" {
+			assert.Equal(t, ReasonCollectorRemoved, item.AssignmentReason)
+		} else {
+			assert.Equal(t, ReasonNewTarget, item.AssignmentReason)
+		}
+	}
+
+	s.SetCollectors([]string{"col-1", "col-3"})
+	s.ReallocateCollectors()
+	rebalanced := 0
+	for _, item := range s.Snapshot().TargetItems() {
+		if item.Collector.Name == "col-3" {
+			assert.Equal(t, ReasonRebalanced, item.AssignmentReason)
+			rebalanced++
+		}
+	}
+	assert.Equal(t, 2, rebalanced)
+
+	history := s.Snapshot().History()
+	assert.Len(t, history, 8)
+	last := history[len(history)-1]
+	assert.Equal(t, "col-1", last.From)
+	assert.Equal(t, "col-3", last.To)
+	assert.Equal(t, ReasonRebalanced, last.Reason)
+}
+
+func TestHistorySize(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy(), WithHistorySize(3))
+	s.SetCollectors([]string{"col-1"})
+	s.SetWaitingTargets(makeTargets(10))
+	s.AllocateTargets()
+	assert.Len(t, s.Snapshot().History(), 3)
+
+	s = NewAllocator(logger, newLeastWeightedStrategy(), WithHistorySize(0))
+	s.SetCollectors([]string{"col-1"})
+	s.SetWaitingTargets(makeTargets(10))
+	s.AllocateTargets()
+	assert.Empty(t, s.Snapshot().History())
+}
+
+func TestAllocationDebug(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetWeightedCollectors(map[string]float64{"col-1": 1, "col-2": 2})
+	s.SetWaitingTargets(append(makeTargets(3), makeJobTargets("other-job", 3, 0)...))
+	s.AllocateTargets()
+	snapshot := s.Snapshot()
+
+	collectors := GetCollectors(snapshot)
+	assert.Len(t, collectors, 2)
+	assert.Equal(t, 2.0, collectors["col-2"].Weight)
+	assert.Equal(t, 6, collectors["col-1"].NumTargets+collectors["col-2"].NumTargets)
+
+	debug := GetAllocationDebug(snapshot, "", "")
+	assert.Equal(t, snapshot.Version, debug.Version)
+	assert.Len(t, debug.Targets, 6)
+	assert.Len(t, debug.History, 6)
+
+	debug = GetAllocationDebug(snapshot, "sample-name", "prometheus:1001")
+	if assert.Len(t, debug.Targets, 1) {
+		target := debug.Targets[0]
+		assert.Equal(t, snapshot.TargetItems()["sample-nameprometheus:1001"].Collector.Name, target.Collector)
+		assert.Equal(t, ReasonNewTarget, target.Reason)
+	}
+	assert.Len(t, debug.History, 1)
+	assert.Len(t, debug.Collectors, 2)
+}
@@ -3,6 +3,8 @@ package allocation
 import (
 	"fmt"
 	"net/url"
+	"sort"
+	"time"
 
 	"github.com/prometheus/common/model"
 )
@@ -12,8 +14,9 @@ type LinkJSON struct {
 }
 
 type collectorJSON struct {
-	Link string            `json:"_link"`
-	Jobs []targetGroupJSON `json:"targets"`
+	Link   string            `json:"_link"`
+	Weight float64           `json:"weight"`
+	Jobs   []targetGroupJSON `json:"targets"`
 }
 
 type targetGroupJSON struct {
@@ -21,56 +24,139 @@ type targetGroupJSON struct {
 	Labels  model.LabelSet `json:"labels"`
 }
 
-func GetAllTargetsByJob(job string, cMap map[string][]TargetItem, allocator *Allocator) map[string]collectorJSON {
+// GetAllTargetsByJob returns the target groups of the given job for every collector which has targets of it.
+func GetAllTargetsByJob(job string, snapshot *Snapshot) map[string]collectorJSON {
 	displayData := make(map[string]collectorJSON)
-	for _, j := range allocator.TargetItems {
-		if j.JobName == job {
-			var targetList []TargetItem
-			targetList = append(targetList, cMap[j.Collector.Name+j.JobName]...)
-
-			var targetGroupList []targetGroupJSON
-
-			trg := make(map[string][]TargetItem)
-			for _, t := range targetList {
-				trg[t.JobName+t.Label.String()] = append(trg[t.JobName+t.Label.String()], t)
-			}
-			labelSetMap := make(map[string]model.LabelSet)
-			for _, tArr := range trg {
-				var targets []string
-				for _, t := range tArr {
-					labelSetMap[t.TargetURL] = t.Label
-					targets = append(targets, t.TargetURL)
-				}
-				targetGroupList = append(targetGroupList, targetGroupJSON{Targets: targets, Labels: labelSetMap[targets[0]]})
-
-			}
-			displayData[j.Collector.Name] = collectorJSON{Link: fmt.Sprintf("/jobs/%s/targets?collector_id=%s", url.QueryEscape(j.JobName), j.Collector.Name), Jobs: targetGroupList}
-
+	for col, groups := range snapshot.targetGroups[job] {
+		displayData[col] = collectorJSON{
+			Link:   fmt.Sprintf("/jobs/%s/targets?collector_id=%s", url.QueryEscape(job), col),
+			Weight: snapshot.collectors[col].weight(),
+			Jobs:   groups,
 		}
 	}
 	return displayData
 }
 
-func GetAllTargetsByCollectorAndJob(collector string, job string, cMap map[string][]TargetItem, allocator *Allocator) []targetGroupJSON {
-	var tgs []targetGroupJSON
-	group := make(map[string][]string)
-	labelSet := make(map[string]model.LabelSet)
-	for _, col := range allocator.collectors {
-		if col.Name == collector {
-			for _, targetItemArr := range cMap {
-				for _, targetItem := range targetItemArr {
-					if targetItem.Collector.Name == collector && targetItem.JobName == job {
-						group[targetItem.Label.String()] = append(group[targetItem.Label.String()], targetItem.TargetURL)
-						labelSet[targetItem.TargetURL] = targetItem.Label
-					}
-				}
-			}
+// GetAllTargetsByCollectorAndJob returns the target groups of the given job which are allocated to the given collector.
+func GetAllTargetsByCollectorAndJob(collector string, job string, snapshot *Snapshot) []targetGroupJSON {
+	return snapshot.targetGroups[job][collector]
+}
+
+// GetAllTargetsByCollector returns the target groups of every job which has targets allocated to the given collector,
+// indexed by job name.
+func GetAllTargetsByCollector(collector string, snapshot *Snapshot) map[string][]targetGroupJSON {
+	jobs := snapshot.collectorTargetGroups[collector]
+	if jobs == nil {
+		return map[string][]targetGroupJSON{}
+	}
+	return jobs
+}
+
+type collectorSummaryJSON struct {
+	Weight     float64 `json:"weight"`
+	NodeName   string  `json:"node_name,omitempty"`
+	NumTargets int     `json:"num_targets"`
+	Cost       float64 `json:"cost"`
+}
+
+type targetDebugJSON struct {
+	JobName    string         `json:"job_name"`
+	Target     string         `json:"target"`
+	Labels     model.LabelSet `json:"labels"`
+	NodeName   string         `json:"node_name,omitempty"`
+	Collector  string         `json:"collector"`
+	Cost       float64        `json:"cost"`
+	AssignedAt time.Time      `json:"assigned_at"`
+	Reason     string         `json:"reason"`
+}
+
+type unassignedTargetJSON struct {
+	JobName  string         `json:"job_name"`
+	Target   string         `json:"target"`
+	Labels   model.LabelSet `json:"labels"`
+	NodeName string         `json:"node_name,omitempty"`
+}
+
+type allocationDebugJSON struct {
+	Version    uint64                          `json:"version"`
+	Collectors map[string]collectorSummaryJSON `json:"collectors"`
+	Targets    []targetDebugJSON               `json:"targets"`
+	Unassigned []unassignedTargetJSON          `json:"unassigned"`
+	History    []Assignment                    `json:"history"`
+}
+
+// GetCollectors returns the weight, number of targets and cost of every collector.
+func GetCollectors(snapshot *Snapshot) map[string]collectorSummaryJSON {
+	collectors := make(map[string]collectorSummaryJSON, len(snapshot.collectors))
+	for name, col := range snapshot.collectors {
+		collectors[name] = collectorSummaryJSON{
+			Weight:     col.weight(),
+			NodeName:   col.NodeName,
+			NumTargets: col.NumTargets,
+			Cost:       col.Cost,
+		}
+	}
+	return collectors
+}
+
+// GetAllocationDebug returns the collectors, the targets with the collector they're assigned to and why, the targets
+// no collector could be assigned to, and the recent assignments. The targets can be restricted to a job and to a target URL, empty values match all of them.
+func GetAllocationDebug(snapshot *Snapshot, job string, target string) allocationDebugJSON {
+	targets := []targetDebugJSON{}
+	for _, item := range snapshot.targetItems {
+		if (job != "" && item.JobName != job) || (target != "" && item.TargetURL != target) {
+			continue
+		}
+		targets = append(targets, targetDebugJSON{
+			JobName:    item.JobName,
+			Target:     item.TargetURL,
+			Labels:     item.Label,
+			NodeName:   item.NodeName,
+			Collector:  item.Collector.Name,
+			Cost:       item.Cost,
+			AssignedAt: item.AssignedAt,
+			Reason:     item.AssignmentReason,
+		})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].JobName != targets[j].JobName {
+			return targets[i].JobName < targets[j].JobName
+		}
+		return targets[i].Target < targets[j].Target
+	})
+
+	unassigned := []unassignedTargetJSON{}
+	for _, item := range snapshot.unassigned {
+		if (job != "" && item.JobName != job) || (target != "" && item.TargetURL != target) {
+			continue
 		}
+		unassigned = append(unassigned, unassignedTargetJSON{
+			JobName:  item.JobName,
+			Target:   item.TargetURL,
+			Labels:   item.Label,
+			NodeName: item.NodeName,
+		})
 	}
+	sort.Slice(unassigned, func(i, j int) bool {
+		if unassigned[i].JobName != unassigned[j].JobName {
+			return unassigned[i].JobName < unassigned[j].JobName
+		}
+		return unassigned[i].Target < unassigned[j].Target
+	})
 
-	for _, v := range group {
-		tgs = append(tgs, targetGroupJSON{Targets: v, Labels: labelSet[v[0]]})
+	history := []Assignment{}
+	for _, assignment := range snapshot.history {
+		if (job != "" && assignment.JobName != job) || (target != "" && assignment.Target != target) {
+			continue
+		}
+		history = append(history, assignment)
 	}
 
-	return tgs
+	return allocationDebugJSON{
+		Version:    snapshot.Version,
+		Collectors: GetCollectors(snapshot),
+		Targets:    targets,
+		Unassigned: unassigned,
+		History:    history,
+	}
 }
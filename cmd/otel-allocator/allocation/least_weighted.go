@@ -0,0 +1,99 @@
+package allocation
+
+import "sort"
+
+var _ AllocationStrategy = &leastWeightedStrategy{}
+
+// leastWeightedStrategy picks the collector with the least cost of targets relative to its weight.
+// Targets stay on their collector for as long as it exists, new collectors get new targets first
+// and can optionally take over targets from the most loaded collectors when rebalancing.
+type leastWeightedStrategy struct{}
+
+func newLeastWeightedStrategy() AllocationStrategy {
+	return &leastWeightedStrategy{}
+}
+
+func (s *leastWeightedStrategy) Name() string {
+	return LeastWeightedStrategy
+}
+
+func (s *leastWeightedStrategy) SetCollectors(_ map[string]*collector) {}
+
+// Next finds the collector with the least cost of targets, relative to its weight, once it got the target.
+func (s *leastWeightedStrategy) Next(_ string, target *TargetItem, collectors map[string]*collector) *collector {
+	cost := target.Cost
+	var col *collector
+	for _, v := range collectors {
+		// If the initial collector is empty, set the initial collector to the first element of map
+		if col == nil {
+			col = v
+		} else {
+			if v.load(v.Cost+cost) < col.load(col.Cost+cost) {
+				col = v
+			}
+		}
+
+	}
+	return col
+}
+
+// Rebalance moves targets from the most loaded to the least loaded collectors, relative to their weights,
+// until moving another target wouldn't lower the load of the most loaded collector or the limit is reached.
+func (s *leastWeightedStrategy) Rebalance(targets map[string]*TargetItem, collectors map[string]*collector, limit int) map[string]*collector {
+	moves := make(map[string]*collector)
+	if limit <= 0 || len(collectors) < 2 {
+		return moves
+	}
+
+	costs := make(map[string]float64, len(collectors))
+	names := make([]string, 0, len(collectors))
+	for name, col := range collectors {
+		costs[name] = col.Cost
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owned := make(map[string][]string, len(collectors))
+	for k, item := range targets {
+		owned[item.Collector.Name] = append(owned[item.Collector.Name], k)
+	}
+	for _, keys := range owned {
+		sort.Strings(keys)
+	}
+
+	for len(moves) < limit {
+		least, most := names[0], names[0]
+		for _, name := range names[1:] {
+			if collectors[name].load(costs[name]) < collectors[least].load(costs[least]) {
+				least = name
+			}
+			if collectors[name].load(costs[name]) > collectors[most].load(costs[most]) {
+				most = name
+			}
+		}
+		if least == most {
+			break
+		}
+
+		// move the last target, in key order, which lowers the load of the most loaded collector
+		// without making the least loaded one the new most loaded
+		keys := owned[most]
+		i := len(keys) - 1
+		for ; i >= 0; i-- {
+			cost := targets[keys[i]].Cost
+			if collectors[least].load(costs[least]+cost) < collectors[most].load(costs[most]) {
+				break
+			}
+		}
+		if i < 0 {
+			break
+		}
+
+		k := keys[i]
+		owned[most] = append(keys[:i], keys[i+1:]...)
+		moves[k] = collectors[least]
+		costs[most] -= targets[k].Cost
+		costs[least] += targets[k].Cost
+	}
+	return moves
+}
@@ -0,0 +1,52 @@
+package allocation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests least connection - The expected collector after running Next should be the collector with the least amount of workload
+func TestFindNextCollector(t *testing.T) {
+	s := newLeastWeightedStrategy()
+
+	defaultCol := collector{Name: "default-col", NumTargets: 1, Cost: 1}
+	maxCol := collector{Name: "max-col", NumTargets: 2, Cost: 2}
+	leastCol := collector{Name: "least-col", NumTargets: 0, Cost: 0}
+	collectors := map[string]*collector{
+		maxCol.Name:     &maxCol,
+		leastCol.Name:   &leastCol,
+		defaultCol.Name: &defaultCol,
+	}
+	s.SetCollectors(collectors)
+
+	assert.Equal(t, "least-col", s.Next("target", &TargetItem{Cost: 1}, collectors).Name)
+}
+
+func TestLeastWeightedDistributesByWeight(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetWeightedCollectors(map[string]float64{"small": 1, "large": 3})
+
+	targets := makeTargets(400)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	assert.Equal(t, 100, s.collectors["small"].NumTargets)
+	assert.Equal(t, 300, s.collectors["large"].NumTargets)
+}
+
+func TestLeastWeightedRebalanceByWeight(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy(), WithRebalanceLimit(1000))
+	s.SetWeightedCollectors(map[string]float64{"col-1": 1})
+
+	targets := makeTargets(300)
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	// the new collector is twice as big, it takes over two thirds of the targets
+	s.SetWeightedCollectors(map[string]float64{"col-1": 1, "col-2": 2})
+	s.ReallocateCollectors()
+
+	assert.Equal(t, 100, s.collectors["col-1"].NumTargets)
+	assert.Equal(t, 200, s.collectors["col-2"].NumTargets)
+}
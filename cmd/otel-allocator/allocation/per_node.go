@@ -0,0 +1,61 @@
+package allocation
+
+import "sort"
+
+var _ AllocationStrategy = &perNodeStrategy{}
+
+// perNodeStrategy assigns every target to the collector running on the same node, for collectors deployed as a
+// DaemonSet. Targets whose node is unknown, or without a collector on their node, aren't assigned at all.
+type perNodeStrategy struct {
+	// nodes holds the name of the collector of every node
+	nodes map[string]string
+}
+
+func newPerNodeStrategy() AllocationStrategy {
+	return &perNodeStrategy{
+		nodes: make(map[string]string),
+	}
+}
+
+func (s *perNodeStrategy) Name() string {
+	return PerNodeStrategy
+}
+
+// SetCollectors indexes the collectors by node. While a DaemonSet is rolled out there can be two collectors
+// on the same node, the first one by name is picked so that the choice doesn't depend on the order of events.
+func (s *perNodeStrategy) SetCollectors(collectors map[string]*collector) {
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s.nodes = make(map[string]string, len(collectors))
+	for _, name := range names {
+		node := collectors[name].NodeName
+		if _, ok := s.nodes[node]; !ok && len(node) > 0 {
+			s.nodes[node] = name
+		}
+	}
+}
+
+// Next returns the collector on the node of the target, if any.
+func (s *perNodeStrategy) Next(_ string, target *TargetItem, collectors map[string]*collector) *collector {
+	name, ok := s.nodes[target.NodeName]
+	if !ok {
+		return nil
+	}
+	return collectors[name]
+}
+
+// Rebalance moves every target which isn't on the collector of its node, e.g. because the collector on that node
+// was replaced. The limit is ignored, a target on the wrong node would be scraped from another node.
+func (s *perNodeStrategy) Rebalance(targets map[string]*TargetItem, collectors map[string]*collector, _ int) map[string]*collector {
+	moves := make(map[string]*collector)
+	for k, item := range targets {
+		if col := s.Next(k, item, collectors); col != nil && col != item.Collector {
+			moves[k] = col
+		}
+	}
+	return moves
+}
@@ -0,0 +1,69 @@
+package allocation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeNodeTargets(nodes ...string) []TargetItem {
+	var targets []TargetItem
+	for i, node := range nodes {
+		targets = append(targets, TargetItem{JobName: "kubelet", TargetURL: fmt.Sprintf("10.0.0.%d:10250", i), Label: model.LabelSet{}, NodeName: node})
+	}
+	return targets
+}
+
+func TestPerNodeAssignsTargetsToTheirNode(t *testing.T) {
+	s := NewAllocator(logger, newPerNodeStrategy())
+	s.SetCollectorInfo(map[string]CollectorInfo{
+		"col-a": {Weight: 1, NodeName: "node-a"},
+		"col-b": {Weight: 1, NodeName: "node-b"},
+	})
+	s.SetWaitingTargets(makeNodeTargets("node-a", "node-b", "node-b", "node-c", ""))
+	s.AllocateTargets()
+
+	assert.Equal(t, map[string]string{
+		"kubelet10.0.0.0:10250": "col-a",
+		"kubelet10.0.0.1:10250": "col-b",
+		"kubelet10.0.0.2:10250": "col-b",
+	}, assignments(s))
+	// the targets without a node, or on a node without a collector, are reported as unassigned
+	assert.Equal(t, []unassignedTargetJSON{
+		{JobName: "kubelet", Target: "10.0.0.3:10250", Labels: model.LabelSet{}, NodeName: "node-c"},
+		{JobName: "kubelet", Target: "10.0.0.4:10250", Labels: model.LabelSet{}},
+	}, GetAllocationDebug(s.Snapshot(), "", "").Unassigned)
+
+	// the targets of a node without a collector are assigned once one is running there
+	s.SetCollectorInfo(map[string]CollectorInfo{
+		"col-a": {Weight: 1, NodeName: "node-a"},
+		"col-b": {Weight: 1, NodeName: "node-b"},
+		"col-c": {Weight: 1, NodeName: "node-c"},
+	})
+	s.ReallocateCollectors()
+	assert.Equal(t, "col-c", assignments(s)["kubelet10.0.0.3:10250"])
+	assert.Len(t, assignments(s), 4)
+	assert.Len(t, GetAllocationDebug(s.Snapshot(), "", "").Unassigned, 1)
+}
+
+func TestPerNodeCollectorReplaced(t *testing.T) {
+	s := NewAllocator(logger, newPerNodeStrategy())
+	s.SetCollectorInfo(map[string]CollectorInfo{"col-b": {Weight: 1, NodeName: "node-a"}})
+	s.SetWaitingTargets(makeNodeTargets("node-a"))
+	s.AllocateTargets()
+	assert.Equal(t, "col-b", assignments(s)["kubelet10.0.0.0:10250"])
+
+	// while the DaemonSet is rolled out, the first collector by name on the node gets the targets
+	s.SetCollectorInfo(map[string]CollectorInfo{
+		"col-a": {Weight: 1, NodeName: "node-a"},
+		"col-b": {Weight: 1, NodeName: "node-a"},
+	})
+	s.ReallocateCollectors()
+	assert.Equal(t, "col-a", assignments(s)["kubelet10.0.0.0:10250"])
+
+	s.SetCollectorInfo(map[string]CollectorInfo{"col-c": {Weight: 1, NodeName: "node-a"}})
+	s.ReallocateCollectors()
+	assert.Equal(t, "col-c", assignments(s)["kubelet10.0.0.0:10250"])
+}
@@ -0,0 +1,147 @@
+package allocation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// Snapshot is an immutable view of the allocation, published by the Allocator after every allocation round.
+// Readers, like the HTTP handlers, must only read from a Snapshot and never from the Allocator's internal state,
+// which is modified concurrently by the service discovery and the collector watcher.
+type Snapshot struct {
+	// Version is incremented every time a new snapshot is published.
+	Version uint64
+
+	targetItems map[string]*TargetItem
+	collectors  map[string]*collector
+	jobs        map[string]LinkJSON
+
+	// targetGroups is indexed by job name and collector name
+	targetGroups map[string]map[string][]targetGroupJSON
+	// collectorTargetGroups holds the same target groups, indexed by collector name and job name
+	collectorTargetGroups map[string]map[string][]targetGroupJSON
+
+	history []Assignment
+	// unassigned holds the targets no collector could be assigned to
+	unassigned []TargetItem
+
+	// encodedMtx guards encoded, which caches the encoded targets of the collectors which were asked for them
+	encodedMtx sync.Mutex
+	encoded    map[string]EncodedTargets
+}
+
+// EncodedTargets is the JSON encoding of the target groups of every job of a collector, along with an ETag derived
+// from it. As the ETag only depends on the content, it doesn't change with snapshots which didn't change the targets
+// of the collector, and is the same on every replica agreeing on the allocation.
+type EncodedTargets struct {
+	Body []byte
+	ETag string
+}
+
+// newSnapshot copies the current state of the allocator into a new snapshot and builds its indexes.
+// It must be called with the allocator's lock held.
+func newSnapshot(version uint64, targetItems map[string]*TargetItem, collectors map[string]*collector, history []Assignment, unassigned []TargetItem) *Snapshot {
+	s := &Snapshot{
+		Version:      version,
+		history:      append([]Assignment(nil), history...),
+		unassigned:   unassigned,
+		targetItems:  make(map[string]*TargetItem, len(targetItems)),
+		collectors:   make(map[string]*collector, len(collectors)),
+		jobs:         make(map[string]LinkJSON),
+		targetGroups: make(map[string]map[string][]targetGroupJSON),
+
+		collectorTargetGroups: make(map[string]map[string][]targetGroupJSON),
+		encoded:               make(map[string]EncodedTargets),
+	}
+	for name, col := range collectors {
+		c := *col
+		s.collectors[name] = &c
+	}
+
+	// job -> collector -> labels -> target urls
+	grouped := make(map[string]map[string]map[string][]string)
+	labels := make(map[string]TargetItem)
+	for k, item := range targetItems {
+		col, ok := s.collectors[item.Collector.Name]
+		if !ok {
+			continue
+		}
+		t := *item
+		t.Collector = col
+		s.targetItems[k] = &t
+		s.jobs[t.JobName] = t.Link
+
+		if _, ok := grouped[t.JobName]; !ok {
+			grouped[t.JobName] = make(map[string]map[string][]string)
+		}
+		if _, ok := grouped[t.JobName][col.Name]; !ok {
+			grouped[t.JobName][col.Name] = make(map[string][]string)
+		}
+		labelKey := t.Label.String()
+		grouped[t.JobName][col.Name][labelKey] = append(grouped[t.JobName][col.Name][labelKey], t.TargetURL)
+		labels[labelKey] = t
+	}
+
+	for job, byCollector := range grouped {
+		s.targetGroups[job] = make(map[string][]targetGroupJSON, len(byCollector))
+		for col, byLabels := range byCollector {
+			keys := make([]string, 0, len(byLabels))
+			for labelKey := range byLabels {
+				keys = append(keys, labelKey)
+			}
+			sort.Strings(keys)
+
+			groups := make([]targetGroupJSON, 0, len(keys))
+			for _, labelKey := range keys {
+				targets := byLabels[labelKey]
+				sort.Strings(targets)
+				groups = append(groups, targetGroupJSON{Targets: targets, Labels: labels[labelKey].Label})
+			}
+			s.targetGroups[job][col] = groups
+
+			if _, ok := s.collectorTargetGroups[col]; !ok {
+				s.collectorTargetGroups[col] = make(map[string][]targetGroupJSON)
+			}
+			s.collectorTargetGroups[col][job] = groups
+		}
+	}
+	return s
+}
+
+// Jobs returns the links to the targets of every job with at least one target.
+func (s *Snapshot) Jobs() map[string]LinkJSON {
+	return s.jobs
+}
+
+// TargetItems returns the allocated targets, indexed by job name and target URL.
+func (s *Snapshot) TargetItems() map[string]*TargetItem {
+	return s.targetItems
+}
+
+// History returns the most recent assignments of targets to collectors, oldest first.
+func (s *Snapshot) History() []Assignment {
+	return s.history
+}
+
+// CollectorTargets returns the encoded target groups of every job of the collector, indexed by job name. The encoding
+// is computed once per snapshot and collector, so that polling an unchanged allocation is cheap.
+func (s *Snapshot) CollectorTargets(collector string) (EncodedTargets, error) {
+	s.encodedMtx.Lock()
+	defer s.encodedMtx.Unlock()
+	if encoded, ok := s.encoded[collector]; ok {
+		return encoded, nil
+	}
+
+	// maps are encoded with sorted keys, and the target groups are sorted, so equal targets give equal bodies
+	body, err := json.Marshal(GetAllTargetsByCollector(collector, s))
+	if err != nil {
+		return EncodedTargets{}, err
+	}
+	sum := sha256.Sum256(body)
+	encoded := EncodedTargets{Body: body, ETag: `"` + hex.EncodeToString(sum[:16]) + `"`}
+	s.encoded[collector] = encoded
+	return encoded, nil
+}
@@ -0,0 +1,165 @@
+package allocation
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotIndexes(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1"})
+	s.SetWaitingTargets([]TargetItem{
+		{JobName: "job-a", TargetURL: "10.0.0.2:8080", Label: model.LabelSet{"pod": "a"}},
+		{JobName: "job-a", TargetURL: "10.0.0.1:8080", Label: model.LabelSet{"pod": "a"}},
+		{JobName: "job-a", TargetURL: "10.0.0.3:8080", Label: model.LabelSet{"pod": "b"}},
+		{JobName: "job-b", TargetURL: "10.0.0.4:8080", Label: model.LabelSet{}},
+	})
+	s.AllocateTargets()
+
+	snapshot := s.Snapshot()
+	assert.Len(t, snapshot.TargetItems(), 4)
+	assert.Equal(t, map[string]LinkJSON{
+		"job-a": {Link: "/jobs/job-a/targets"},
+		"job-b": {Link: "/jobs/job-b/targets"},
+	}, snapshot.Jobs())
+
+	assert.Equal(t, []targetGroupJSON{
+		{Targets: []string{"10.0.0.1:8080", "10.0.0.2:8080"}, Labels: model.LabelSet{"pod": "a"}},
+		{Targets: []string{"10.0.0.3:8080"}, Labels: model.LabelSet{"pod": "b"}},
+	}, GetAllTargetsByCollectorAndJob("col-1", "job-a", snapshot))
+	assert.Empty(t, GetAllTargetsByCollectorAndJob("col-2", "job-a", snapshot))
+
+	byJob := GetAllTargetsByJob("job-b", snapshot)
+	assert.Len(t, byJob, 1)
+	assert.Equal(t, "/jobs/job-b/targets?collector_id=col-1", byJob["col-1"].Link)
+	assert.Equal(t, 1.0, byJob["col-1"].Weight)
+}
+
+func TestCollectorTargets(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1"})
+	targets := []TargetItem{
+		{JobName: "job-a", TargetURL: "10.0.0.1:8080", Label: model.LabelSet{"pod": "a"}},
+		{JobName: "job-b", TargetURL: "10.0.0.2:8080", Label: model.LabelSet{}},
+	}
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+
+	snapshot := s.Snapshot()
+	assert.Equal(t, map[string][]targetGroupJSON{
+		"job-a": {{Targets: []string{"10.0.0.1:8080"}, Labels: model.LabelSet{"pod": "a"}}},
+		"job-b": {{Targets: []string{"10.0.0.2:8080"}, Labels: model.LabelSet{}}},
+	}, GetAllTargetsByCollector("col-1", snapshot))
+
+	encoded, err := snapshot.CollectorTargets("col-1")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"job-a": [{"targets": ["10.0.0.1:8080"], "labels": {"pod": "a"}}],
+		"job-b": [{"targets": ["10.0.0.2:8080"], "labels": {}}]
+	}`, string(encoded.Body))
+	assert.NotEmpty(t, encoded.ETag)
+
+	unknown, err := snapshot.CollectorTargets("col-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(unknown.Body))
+	assert.NotEqual(t, encoded.ETag, unknown.ETag)
+
+	// the ETag only depends on the targets of the collector, not on the snapshot
+	s.SetWaitingTargets(targets)
+	s.AllocateTargets()
+	unchanged, err := s.Snapshot().CollectorTargets("col-1")
+	assert.NoError(t, err)
+	assert.Equal(t, encoded.ETag, unchanged.ETag)
+
+	s.SetWaitingTargets(targets[:1])
+	s.AllocateTargets()
+	changed, err := s.Snapshot().CollectorTargets("col-1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, encoded.ETag, changed.ETag)
+}
+
+func TestSnapshotIsNotModifiedByLaterAllocations(t *testing.T) {
+	s := NewAllocator(logger, newLeastWeightedStrategy())
+	s.SetCollectors([]string{"col-1", "col-2"})
+	s.SetWaitingTargets(makeTargets(10))
+	s.AllocateTargets()
+
+	snapshot := s.Snapshot()
+	before := make(map[string]string)
+	for k, item := range snapshot.TargetItems() {
+		before[k] = item.Collector.Name
+	}
+
+	s.SetCollectors([]string{"col-1"})
+	s.ReallocateCollectors()
+	s.SetWaitingTargets(makeTargets(5))
+	s.AllocateTargets()
+
+	assert.Greater(t, s.Snapshot().Version, snapshot.Version)
+	assert.Len(t, snapshot.TargetItems(), 10)
+	for k, item := range snapshot.TargetItems() {
+		assert.Equal(t, before[k], item.Collector.Name)
+	}
+}
+
+// Tests that reading the snapshot while targets and collectors change is free of data races, run with -race
+func TestConcurrentAllocationAndReads(t *testing.T) {
+	s := NewAllocator(logger, newConsistentHashingStrategy())
+	s.SetCollectors([]string{"col-1", "col-2"})
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.SetWaitingTargets(makeTargets(50 + i%20))
+			s.AllocateTargets()
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.SetCollectors([]string{"col-1", "col-2", fmt.Sprintf("col-%d", 3+i%3)})
+			s.ReallocateCollectors()
+		}
+	}()
+
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				snapshot := s.Snapshot()
+				for job := range snapshot.Jobs() {
+					for col := range GetAllTargetsByJob(job, snapshot) {
+						GetAllTargetsByCollectorAndJob(col, job, snapshot)
+						_, _ = snapshot.CollectorTargets(col)
+					}
+				}
+				for _, item := range snapshot.TargetItems() {
+					_ = item.Collector.NumTargets
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	assert.Len(t, s.Snapshot().TargetItems(), 50+99%20)
+}
@@ -0,0 +1,67 @@
+package allocation
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// LeastWeightedStrategy assigns every new target to the collector currently holding the fewest targets.
+	LeastWeightedStrategy = "least-weighted"
+
+	// ConsistentHashingStrategy assigns targets based on a consistent hash ring built from the collector names,
+	// so that adding or removing a collector only moves the targets owned by that collector.
+	ConsistentHashingStrategy = "consistent-hashing"
+
+	// PerNodeStrategy assigns every target to the collector running on the same node as the target,
+	// for collectors deployed as a DaemonSet.
+	PerNodeStrategy = "per-node"
+
+	// DefaultStrategy is the strategy used when none is configured.
+	DefaultStrategy = LeastWeightedStrategy
+)
+
+// AllocationStrategy decides which collector a target is assigned to.
+// The Allocator holds the lock while calling any of these methods, so implementations
+// don't need to be safe for concurrent use.
+type AllocationStrategy interface {
+	// Name returns the name the strategy was registered with.
+	Name() string
+
+	// SetCollectors is called every time the set of collectors changes, before any targets are reallocated.
+	SetCollectors(collectors map[string]*collector)
+
+	// Next returns the collector which should be responsible for the target identified by the given key,
+	// or nil if there's no collector available. The target's collector isn't set yet, its cost is the share
+	// of work it adds to its collector.
+	Next(key string, target *TargetItem, collectors map[string]*collector) *collector
+
+	// Rebalance is called after the set of collectors changed and the targets of removed collectors were
+	// reassigned. It returns the targets, by key, which should move to another collector. The limit is
+	// the maximum number of targets the strategy should move, strategies with a fixed placement may ignore it.
+	Rebalance(targets map[string]*TargetItem, collectors map[string]*collector, limit int) map[string]*collector
+}
+
+var strategies = map[string]func() AllocationStrategy{
+	LeastWeightedStrategy:     newLeastWeightedStrategy,
+	ConsistentHashingStrategy: newConsistentHashingStrategy,
+	PerNodeStrategy:           newPerNodeStrategy,
+}
+
+// NewStrategy returns a new instance of the allocation strategy registered with the given name.
+func NewStrategy(name string) (AllocationStrategy, error) {
+	if fn, ok := strategies[name]; ok {
+		return fn(), nil
+	}
+	return nil, fmt.Errorf("unknown allocation strategy %q, must be one of %v", name, GetRegisteredStrategyNames())
+}
+
+// GetRegisteredStrategyNames returns the sorted names of all known allocation strategies.
+func GetRegisteredStrategyNames() []string {
+	var names []string
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
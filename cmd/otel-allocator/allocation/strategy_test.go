@@ -0,0 +1,18 @@
+package allocation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range GetRegisteredStrategyNames() {
+		s, err := NewStrategy(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, s.Name())
+	}
+
+	_, err := NewStrategy("round-robin")
+	assert.Error(t, err)
+}
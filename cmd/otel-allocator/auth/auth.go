@@ -0,0 +1,138 @@
+// Package auth secures the endpoints of the TargetAllocator with TLS, client certificates and bearer tokens.
+package auth
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otel-allocator/config"
+)
+
+var (
+	// ErrNoCertificates is returned when the client CA file doesn't contain any PEM encoded certificate.
+	ErrNoCertificates = errors.New("no certificates found in the client CA file")
+)
+
+// reloadingFile caches the content of a file and reads it again once its modification time changes,
+// so that rotated Secrets are picked up without restarting the process.
+type reloadingFile struct {
+	path    string
+	m       sync.Mutex
+	modTime time.Time
+	content []byte
+}
+
+func (f *reloadingFile) read() ([]byte, bool, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.content != nil && info.ModTime().Equal(f.modTime) {
+		return f.content, false, nil
+	}
+	content, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+	f.content = content
+	f.modTime = info.ModTime()
+	return content, true, nil
+}
+
+// certificateReloader loads the key pair again whenever the certificate or the key changed on disk.
+type certificateReloader struct {
+	cert, key *reloadingFile
+	m         sync.Mutex
+	current   *tls.Certificate
+}
+
+func (r *certificateReloader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certPEM, certChanged, err := r.cert.read()
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, keyChanged, err := r.key.read()
+	if err != nil {
+		return nil, err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.current == nil || certChanged || keyChanged {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the key pair: %w", err)
+		}
+		r.current = &cert
+	}
+	return r.current, nil
+}
+
+// NewTLSConfig returns the TLS configuration to serve with. When a client CA file is configured,
+// clients have to present a certificate signed by it.
+func NewTLSConfig(conf config.TLSServerConfig) (*tls.Config, error) {
+	reloader := &certificateReloader{
+		cert: &reloadingFile{path: *conf.CertFile},
+		key:  &reloadingFile{path: *conf.KeyFile},
+	}
+	// fail early on an invalid key pair instead of on the first connection
+	if _, err := reloader.getCertificate(nil); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	if conf.ClientCAFile != nil && len(*conf.ClientCAFile) > 0 {
+		caPEM, err := ioutil.ReadFile(*conf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, ErrNoCertificates
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// BearerTokenHandler rejects every request which doesn't carry the token found in the given file.
+func BearerTokenHandler(tokenFile string, next http.Handler) (http.Handler, error) {
+	file := &reloadingFile{path: tokenFile}
+	if _, _, err := file.read(); err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, err := file.read()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		expected := strings.TrimSpace(string(token))
+		header := r.Header.Get("Authorization")
+		provided := strings.TrimPrefix(header, "Bearer ")
+		if len(expected) == 0 || provided == header || subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/otel-allocator/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCertificate creates a certificate signed by the given parent, or a self-signed one if parent is nil.
+func newCertificate(t *testing.T, name string, parent *tls.Certificate) (tls.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, err = x509.ParseCertificate(parent.Certificate[0])
+		require.NoError(t, err)
+		signerKey = parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return cert, certPEM, keyPEM
+}
+
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+	return path
+}
+
+func TestTLSWithClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca, caPEM, _ := newCertificate(t, "ca", nil)
+	_, serverPEM, serverKeyPEM := newCertificate(t, "target-allocator", &ca)
+	client, _, _ := newCertificate(t, "collector", &ca)
+	other, _, _ := newCertificate(t, "other", nil)
+
+	certFile := writeFile(t, dir, "tls.crt", serverPEM)
+	keyFile := writeFile(t, dir, "tls.key", serverKeyPEM)
+	caFile := writeFile(t, dir, "ca.crt", caPEM)
+
+	tlsConfig, err := NewTLSConfig(config.TLSServerConfig{CertFile: &certFile, KeyFile: &keyFile, ClientCAFile: &caFile})
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = tlsConfig
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+
+	for _, tt := range []struct {
+		name    string
+		certs   []tls.Certificate
+		success bool
+	}{
+		{name: "valid client certificate", certs: []tls.Certificate{client}, success: true},
+		{name: "no client certificate", certs: nil, success: false},
+		{name: "client certificate from another CA", certs: []tls.Certificate{other}, success: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+				RootCAs:      roots,
+				Certificates: tt.certs,
+				ServerName:   "target-allocator",
+			}}}
+			resp, err := httpClient.Get(srv.URL)
+			if tt.success {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				resp.Body.Close()
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTLSInvalidKeyPair(t *testing.T) {
+	dir := t.TempDir()
+	_, certPEM, _ := newCertificate(t, "server", nil)
+	_, _, otherKeyPEM := newCertificate(t, "other", nil)
+	certFile := writeFile(t, dir, "tls.crt", certPEM)
+	keyFile := writeFile(t, dir, "tls.key", otherKeyPEM)
+	caFile := ""
+
+	_, err := NewTLSConfig(config.TLSServerConfig{CertFile: &certFile, KeyFile: &keyFile, ClientCAFile: &caFile})
+	assert.Error(t, err)
+}
+
+func TestBearerToken(t *testing.T) {
+	tokenFile := writeFile(t, t.TempDir(), "token", []byte("s3cr3t\n"))
+	handler, err := BearerTokenHandler(tokenFile, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		header   string
+		expected int
+	}{
+		{name: "valid token", header: "Bearer s3cr3t", expected: http.StatusOK},
+		{name: "invalid token", header: "Bearer wrong", expected: http.StatusUnauthorized},
+		{name: "no token", header: "", expected: http.StatusUnauthorized},
+		{name: "token without scheme", header: "s3cr3t", expected: http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+			if len(tt.header) > 0 {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+
+	// rotating the token takes effect without creating a new handler
+	later := time.Now().Add(time.Minute)
+	writeFile(t, filepath.Dir(tokenFile), "token", []byte("rotated"))
+	require.NoError(t, chtimes(tokenFile, later))
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	req.Header.Set("Authorization", "Bearer rotated")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestBearerTokenMissingFile(t *testing.T) {
+	_, err := BearerTokenHandler(filepath.Join(t.TempDir(), "missing"), http.NotFoundHandler())
+	assert.Error(t, err)
+}
+
+func chtimes(path string, t time.Time) error {
+	return os.Chtimes(path, t, t)
+}
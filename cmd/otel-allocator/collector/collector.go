@@ -3,20 +3,19 @@ package collector
 import (
 	"context"
 	"os"
-	"strconv"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-)
-
-const (
-	watcherTimeout = 15 * time.Minute
+	"k8s.io/client-go/tools/cache"
 )
 
 var (
@@ -24,113 +23,198 @@ var (
 )
 
 type Client struct {
-	log           logr.Logger
-	k8sClient     kubernetes.Interface
-	collectorChan chan []string
-	close         chan struct{}
+	log            logr.Logger
+	k8sClient      kubernetes.Interface
+	weightResource v1.ResourceName
+	// notReadyGracePeriod is how long a collector which stops being ready keeps its targets
+	notReadyGracePeriod time.Duration
+
+	// mtx serializes the callbacks with replacing the watch, so that a replaced watch never reports its collectors
+	// after the new one
+	mtx         sync.Mutex
+	cancelWatch context.CancelFunc
 }
 
-func NewClient(logger logr.Logger, kubeConfig *rest.Config) (*Client, error) {
+func NewClient(logger logr.Logger, kubeConfig *rest.Config, options ...func(*Client)) (*Client, error) {
 	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		return &Client{}, err
 	}
 
-	return &Client{
+	client := &Client{
 		log:       logger,
 		k8sClient: clientset,
-		close:     make(chan struct{}),
-	}, nil
+	}
+	for _, opt := range options {
+		opt(client)
+	}
+	return client, nil
 }
 
-func (k *Client) Watch(ctx context.Context, labelMap map[string]string, fn func(collectors []string)) {
-	collectorMap := map[string]bool{}
-	log := k.log.WithValues("component", "opentelemetry-targetallocator")
+// WithNotReadyGracePeriod lets the collector pods which stop being ready keep their targets for the given duration,
+// so that a short disruption doesn't move their targets away and back. By default, they lose them right away.
+func WithNotReadyGracePeriod(gracePeriod time.Duration) func(*Client) {
+	return func(k *Client) {
+		k.notReadyGracePeriod = gracePeriod
+	}
+}
 
-	opts := metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(labelMap).String(),
+// Watch calls fn with the weight and node of every ready collector pod, indexed by pod name, whenever the set of pods
+// or their weights change. The pods are watched by an informer, which retries with a backoff when the API server
+// can't be reached, and fn is first called once all the pods were listed. Calling Watch again replaces the
+// previous watch, e.g. to apply a new label selector, the previous callback isn't called anymore once it returns.
+func (k *Client) Watch(ctx context.Context, labelMap map[string]string, fn func(collectors map[string]allocation.CollectorInfo)) {
+	k.mtx.Lock()
+	if k.cancelWatch != nil {
+		k.cancelWatch()
 	}
-	pods, err := k.k8sClient.CoreV1().Pods(ns).List(ctx, opts)
-	if err != nil {
-		log.Error(err, "Pod failure")
-		os.Exit(1)
+	ctx, k.cancelWatch = context.WithCancel(ctx)
+	k.mtx.Unlock()
+
+	selector := labels.SelectorFromSet(labelMap).String()
+	factory := informers.NewSharedInformerFactoryWithOptions(k.k8sClient, 0,
+		informers.WithNamespace(ns),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector
+		}),
+	)
+	w := &podWatch{
+		client:        k,
+		ctx:           ctx,
+		informer:      factory.Core().V1().Pods().Informer(),
+		fn:            fn,
+		notReadySince: make(map[string]time.Time),
 	}
-	for i := range pods.Items {
-		pod := pods.Items[i]
-		if pod.GetObjectMeta().GetDeletionTimestamp() == nil {
-			collectorMap[pod.Name] = true
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.update()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.update()
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.update()
+		},
+	})
+
+	go w.informer.Run(ctx.Done())
+	go func() {
+		if !cache.WaitForNamedCacheSync("collector pods", ctx.Done(), w.informer.HasSynced) {
+			return
 		}
+		w.update()
+	}()
+}
+
+// report calls fn with the collectors, unless the watch was replaced or stopped in the meantime.
+func (k *Client) report(ctx context.Context, collectors map[string]allocation.CollectorInfo, fn func(collectors map[string]allocation.CollectorInfo)) bool {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	if ctx.Err() != nil {
+		return false
 	}
+	fn(collectors)
+	return true
+}
 
-	collectorKeys := make([]string, len(collectorMap))
-	i := 0
-	for keys := range collectorMap {
-		collectorKeys[i] = keys
-		i++
+// Close stops watching the collector pods.
+func (k *Client) Close() {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+	if k.cancelWatch != nil {
+		k.cancelWatch()
 	}
-	fn(collectorKeys)
+}
 
-	go func() {
-		for {
-			watcher, err := k.k8sClient.CoreV1().Pods(ns).Watch(ctx, opts)
-			if err != nil {
-				log.Error(err, "unable to create collector pod watcher")
-				return
-			}
-			if msg := runWatch(ctx, k, watcher.ResultChan(), collectorMap, fn); msg != "" {
-				log.Info("Collector pod watch event stopped " + msg)
-				return
-			}
-		}
-	}()
+// podWatch tracks the collector pods matching the label selector of one call of Watch.
+type podWatch struct {
+	client   *Client
+	ctx      context.Context
+	informer cache.SharedIndexInformer
+	fn       func(collectors map[string]allocation.CollectorInfo)
+
+	mtx sync.Mutex
+	// collectors holds the last reported collectors, nil until they were reported once
+	collectors map[string]allocation.CollectorInfo
+	// notReadySince holds when the collectors in their grace period stopped being ready
+	notReadySince map[string]time.Time
 }
 
-func runWatch(ctx context.Context, k *Client, c <-chan watch.Event, collectorMap map[string]bool, fn func(collectors []string)) string {
-	log := k.log.WithValues("component", "opentelemetry-targetallocator")
-	for {
-		select {
-		case <-k.close:
-			return "kubernetes client closed"
-		case <-ctx.Done():
-			return "context done"
-		case event, ok := <-c:
-			if !ok {
-				log.Info(strconv.FormatBool(ok))
-				return "no event"
-			}
+// update computes the collectors from the pods in the informer's store, and reports them if they changed.
+func (w *podWatch) update() {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	// the initial list is reported at once rather than pod by pod
+	if !w.informer.HasSynced() {
+		return
+	}
 
-			pod, ok := event.Object.(*v1.Pod)
-			if !ok {
-				log.Info(strconv.FormatBool(ok))
-				return "no event"
-			}
+	now := time.Now()
+	gracePeriod := w.client.notReadyGracePeriod
+	collectors := make(map[string]allocation.CollectorInfo)
+	for _, obj := range w.informer.GetStore().List() {
+		pod := obj.(*v1.Pod)
+		if pod.GetObjectMeta().GetDeletionTimestamp() != nil {
+			continue
+		}
+		if isReady(pod) {
+			delete(w.notReadySince, pod.Name)
+			collectors[pod.Name] = w.client.info(pod)
+			continue
+		}
 
-			switch event.Type {
-			case watch.Added:
-				collectorMap[pod.Name] = true
-			case watch.Deleted:
-				delete(collectorMap, pod.Name)
+		// pods which never were ready, e.g. pending or crash looping ones, don't get any targets
+		if _, ok := w.collectors[pod.Name]; !ok {
+			continue
+		}
+		since, ok := w.notReadySince[pod.Name]
+		if !ok {
+			since = now
+			w.notReadySince[pod.Name] = since
+			if gracePeriod > 0 {
+				w.client.log.Info("Collector pod isn't ready, keeping its targets during the grace period", "pod", pod.Name, "gracePeriod", gracePeriod)
+				time.AfterFunc(gracePeriod, w.update)
 			}
+		}
+		if now.Sub(since) < gracePeriod {
+			collectors[pod.Name] = w.client.info(pod)
+		} else {
+			w.client.log.Info("Collector pod isn't ready, moving its targets", "pod", pod.Name)
+		}
+	}
+	// forget the pods which lost their targets or are gone
+	for name := range w.notReadySince {
+		if _, ok := collectors[name]; !ok {
+			delete(w.notReadySince, name)
+		}
+	}
 
-			collectorKeys := make([]string, len(collectorMap))
-			i := 0
-			for keys := range collectorMap {
-				collectorKeys[i] = keys
-				i++
-			}
-			fn(collectorKeys)
-			select {
-			case k.collectorChan <- collectorKeys:
-			default:
-				fn(collectorKeys)
-			}
-		case <-time.After(watcherTimeout):
-			log.Info("Restarting watch routine")
-			return ""
+	if w.collectors != nil && reflect.DeepEqual(collectors, w.collectors) {
+		return
+	}
+	w.collectors = collectors
+	w.client.report(w.ctx, copyCollectors(collectors), w.fn)
+}
+
+// isReady returns whether the pod's Ready condition is true.
+func isReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
 		}
 	}
+	return false
 }
 
-func (k *Client) Close() {
-	close(k.close)
+// info describes the collector pod to the allocator.
+func (k *Client) info(pod *v1.Pod) allocation.CollectorInfo {
+	return allocation.CollectorInfo{Weight: k.weight(pod), NodeName: pod.Spec.NodeName}
+}
+
+func copyCollectors(collectorMap map[string]allocation.CollectorInfo) map[string]allocation.CollectorInfo {
+	collectors := make(map[string]allocation.CollectorInfo, len(collectorMap))
+	for name, info := range collectorMap {
+		collectors[name] = info
+	}
+	return collectors
 }
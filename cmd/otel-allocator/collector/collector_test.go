@@ -2,85 +2,154 @@ package collector
 
 import (
 	"context"
-	"fmt"
-	"os"
-	"sort"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
-var client Client
-var collectors = []string{}
-
-func TestMain(m *testing.M) {
-	client = Client{
-		k8sClient:     fake.NewSimpleClientset(),
-		collectorChan: make(chan []string, 3),
-		close:         make(chan struct{}),
-	}
+var labelMap = map[string]string{
+	"app.kubernetes.io/instance":   "default.test",
+	"app.kubernetes.io/managed-by": "opentelemetry-operator",
+}
 
-	labelMap := map[string]string{
-		"app.kubernetes.io/instance":   "default.test",
-		"app.kubernetes.io/managed-by": "opentelemetry-operator",
-	}
+// newTestClient returns a client of a fake clientset with the given pods, and a channel receiving the label selector
+// of every watch started, as events sent before the watch started are lost.
+func newTestClient(pods ...runtime.Object) (*Client, chan string) {
+	clientset := fake.NewSimpleClientset(pods...)
+	watching := make(chan string, 10)
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watching <- action.(k8stesting.WatchAction).GetWatchRestrictions().Labels.String()
+		return false, nil, nil
+	})
+	return &Client{log: logr.Discard(), k8sClient: clientset}, watching
+}
 
-	opts := metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(labelMap).String(),
-	}
+// collectorsReporter returns a callback sending the collectors to the returned channel.
+func collectorsReporter() (func(map[string]allocation.CollectorInfo), chan map[string]allocation.CollectorInfo) {
+	reports := make(chan map[string]allocation.CollectorInfo, 10)
+	return func(collectors map[string]allocation.CollectorInfo) { reports <- collectors }, reports
+}
 
-	watcher, err := client.k8sClient.CoreV1().Pods("test-ns").Watch(context.Background(), opts)
-	if err != nil {
-		fmt.Printf("failed to setup a Collector Pod watcher: %v", err)
-		os.Exit(1)
+func nextReport(t *testing.T, reports chan map[string]allocation.CollectorInfo) map[string]allocation.CollectorInfo {
+	select {
+	case collectors := <-reports:
+		return collectors
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no collectors reported")
+		return nil
 	}
-
-	go runWatch(context.Background(), &client, watcher.ResultChan(), map[string]bool{}, func(collectorList []string) { getCollectors(collectorList) })
-
-	code := m.Run()
-
-	close(client.close)
-
-	os.Exit(code)
 }
 
 func TestWatchPodAddition(t *testing.T) {
-	expected := []string{"test-pod1", "test-pod2", "test-pod3"}
-
-	for _, k := range []string{"test-pod1", "test-pod2", "test-pod3"} {
-		expected := pod(k)
-		_, err := client.k8sClient.CoreV1().Pods("test-ns").Create(context.Background(), expected, metav1.CreateOptions{})
+	client, watching := newTestClient()
+	defer client.Close()
+	fn, reports := collectorsReporter()
+	client.Watch(context.Background(), labelMap, fn)
+	assert.Empty(t, nextReport(t, reports))
+	<-watching
+
+	for _, name := range []string{"test-pod1", "test-pod2", "test-pod3"} {
+		_, err := client.k8sClient.CoreV1().Pods("test-ns").Create(context.Background(), pod(name), metav1.CreateOptions{})
 		assert.NoError(t, err)
-		collectors = <-client.collectorChan
+		assert.Contains(t, nextReport(t, reports), name)
 	}
-
-	assert.Len(t, collectors, 3)
-
-	sort.Strings(collectors)
-	assert.Equal(t, collectors, expected)
 }
 
 func TestWatchPodDeletion(t *testing.T) {
-	expected := []string{"test-pod1"}
-
-	for _, k := range []string{"test-pod2", "test-pod3"} {
-		err := client.k8sClient.CoreV1().Pods("test-ns").Delete(context.Background(), k, metav1.DeleteOptions{})
+	client, watching := newTestClient(pod("test-pod1"), pod("test-pod2"), pod("test-pod3"))
+	defer client.Close()
+	fn, reports := collectorsReporter()
+	client.Watch(context.Background(), labelMap, fn)
+	assert.Len(t, nextReport(t, reports), 3)
+	<-watching
+
+	for _, name := range []string{"test-pod2", "test-pod3"} {
+		err := client.k8sClient.CoreV1().Pods("test-ns").Delete(context.Background(), name, metav1.DeleteOptions{})
 		assert.NoError(t, err)
-		collectors = <-client.collectorChan
+		assert.NotContains(t, nextReport(t, reports), name)
 	}
+	assert.Empty(t, reports)
+}
 
-	assert.Len(t, collectors, 1)
+func TestWatchOnlyReadyPods(t *testing.T) {
+	pending := pod("pending")
+	pending.Status = v1.PodStatus{Phase: v1.PodPending}
+	client, watching := newTestClient(pod("ready"), pending)
+	defer client.Close()
+	fn, reports := collectorsReporter()
+	client.Watch(context.Background(), labelMap, fn)
+	assert.Equal(t, map[string]allocation.CollectorInfo{"ready": {Weight: 1}}, nextReport(t, reports))
+	<-watching
+
+	pods := client.k8sClient.CoreV1().Pods("test-ns")
+	_, err := pods.UpdateStatus(context.Background(), pod("pending"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]allocation.CollectorInfo{"ready": {Weight: 1}, "pending": {Weight: 1}}, nextReport(t, reports))
+
+	notReady := pod("ready")
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	_, err = pods.UpdateStatus(context.Background(), notReady, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]allocation.CollectorInfo{"pending": {Weight: 1}}, nextReport(t, reports))
+}
 
-	sort.Strings(collectors)
-	assert.Equal(t, collectors, expected)
+func TestWatchNotReadyGracePeriod(t *testing.T) {
+	client, watching := newTestClient(pod("test-pod1"), pod("test-pod2"))
+	WithNotReadyGracePeriod(200 * time.Millisecond)(client)
+	defer client.Close()
+	fn, reports := collectorsReporter()
+	client.Watch(context.Background(), labelMap, fn)
+	assert.Len(t, nextReport(t, reports), 2)
+	<-watching
+
+	notReady := pod("test-pod1")
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	start := time.Now()
+	_, err := client.k8sClient.CoreV1().Pods("test-ns").UpdateStatus(context.Background(), notReady, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// the pod keeps its targets until the grace period is over
+	assert.Equal(t, map[string]allocation.CollectorInfo{"test-pod2": {Weight: 1}}, nextReport(t, reports))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
 }
 
-func getCollectors(c []string) {
-	collectors = c
+func TestWatchReplaced(t *testing.T) {
+	withLabel := func(name, team string) *v1.Pod {
+		p := pod(name)
+		p.Labels["team"] = team
+		return p
+	}
+	client, watching := newTestClient(withLabel("pod-a", "a"), withLabel("pod-b", "b"))
+	defer client.Close()
+
+	previous, previousReports := collectorsReporter()
+	client.Watch(context.Background(), map[string]string{"team": "a"}, previous)
+	assert.Equal(t, map[string]allocation.CollectorInfo{"pod-a": {Weight: 1}}, nextReport(t, previousReports))
+
+	current, currentReports := collectorsReporter()
+	client.Watch(context.Background(), map[string]string{"team": "b"}, current)
+	assert.Equal(t, map[string]allocation.CollectorInfo{"pod-b": {Weight: 1}}, nextReport(t, currentReports))
+	for selector := range watching {
+		if selector == "team=b" {
+			break
+		}
+	}
+
+	// only the callback of the current watch is called
+	_, err := client.k8sClient.CoreV1().Pods("test-ns").Create(context.Background(), withLabel("pod-c", "b"), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, nextReport(t, currentReports), "pod-c")
+	assert.Len(t, previousReports, 0)
 }
 
 func pod(name string) *v1.Pod {
@@ -94,5 +163,9 @@ func pod(name string) *v1.Pod {
 			Namespace: "test-ns",
 			Labels:    labels,
 		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		},
 	}
 }
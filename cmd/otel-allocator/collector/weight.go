@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/otel-allocator/allocation"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// WeightAnnotation sets the weight of a collector pod explicitly. It takes precedence over the resource limits.
+	WeightAnnotation = "opentelemetry.io/target-allocator-weight"
+
+	// DefaultWeight is the weight of the collectors without a valid annotation or resource limit.
+	DefaultWeight = 1.0
+
+	bytesPerGiB = 1 << 30
+)
+
+// WithWeightResource derives the weight of every collector pod from the sum of its containers' limits
+// for the given resource, in cores for the CPU and in GiB for the memory.
+func WithWeightResource(resource v1.ResourceName) func(*Client) {
+	return func(k *Client) {
+		k.weightResource = resource
+	}
+}
+
+// weight returns the share of the targets the given collector pod should receive, relative to the other pods.
+// The weight is capped to allocation.MaxWeight.
+func (k *Client) weight(pod *v1.Pod) float64 {
+	if value, ok := pod.Annotations[WeightAnnotation]; ok {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err == nil && weight > 0 && !math.IsInf(weight, 0) {
+			if weight > allocation.MaxWeight {
+				k.log.Info("Capping collector weight", "pod", pod.Name, "weight", value, "max", allocation.MaxWeight)
+				return allocation.MaxWeight
+			}
+			return weight
+		}
+		k.log.Info("Ignoring invalid collector weight", "pod", pod.Name, "weight", value)
+	}
+
+	if len(k.weightResource) == 0 {
+		return DefaultWeight
+	}
+	var total float64
+	for _, container := range pod.Spec.Containers {
+		if limit, ok := container.Resources.Limits[k.weightResource]; ok {
+			total += limit.AsApproximateFloat64()
+		}
+	}
+	if k.weightResource == v1.ResourceMemory {
+		total /= bytesPerGiB
+	}
+	if total <= 0 {
+		return DefaultWeight
+	}
+	return math.Min(total, allocation.MaxWeight)
+}
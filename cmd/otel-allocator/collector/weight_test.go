@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func weightedPod(annotations map[string]string, limits ...v1.ResourceList) *v1.Pod {
+	p := pod("test-pod")
+	p.Annotations = annotations
+	for _, l := range limits {
+		p.Spec.Containers = append(p.Spec.Containers, v1.Container{Resources: v1.ResourceRequirements{Limits: l}})
+	}
+	return p
+}
+
+func TestWeight(t *testing.T) {
+	limits := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("500m"),
+		v1.ResourceMemory: resource.MustParse("2Gi"),
+	}
+	for _, tt := range []struct {
+		name     string
+		resource v1.ResourceName
+		pod      *v1.Pod
+		expected float64
+	}{
+		{name: "no weight", pod: weightedPod(nil, limits), expected: DefaultWeight},
+		{name: "annotation", pod: weightedPod(map[string]string{WeightAnnotation: "2.5"}), expected: 2.5},
+		{name: "annotation overrides limits", resource: v1.ResourceMemory, pod: weightedPod(map[string]string{WeightAnnotation: "3"}, limits), expected: 3},
+		{name: "invalid annotation", pod: weightedPod(map[string]string{WeightAnnotation: "heavy"}), expected: DefaultWeight},
+		{name: "negative annotation", pod: weightedPod(map[string]string{WeightAnnotation: "-1"}), expected: DefaultWeight},
+		{name: "infinite annotation", pod: weightedPod(map[string]string{WeightAnnotation: "+Inf"}), expected: DefaultWeight},
+		{name: "nan annotation", pod: weightedPod(map[string]string{WeightAnnotation: "NaN"}), expected: DefaultWeight},
+		{name: "huge annotation", pod: weightedPod(map[string]string{WeightAnnotation: "1e9"}), expected: allocation.MaxWeight},
+		{name: "huge limits", resource: v1.ResourceCPU, pod: weightedPod(nil, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1000")}), expected: allocation.MaxWeight},
+		{name: "memory limits of all containers", resource: v1.ResourceMemory, pod: weightedPod(nil, limits, limits), expected: 4},
+		{name: "cpu limits", resource: v1.ResourceCPU, pod: weightedPod(nil, limits), expected: 0.5},
+		{name: "no limits", resource: v1.ResourceMemory, pod: weightedPod(nil), expected: DefaultWeight},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Client{log: logr.Discard()}
+			if len(tt.resource) > 0 {
+				WithWeightResource(tt.resource)(k)
+			}
+			assert.Equal(t, tt.expected, k.weight(tt.pod))
+		})
+	}
+}
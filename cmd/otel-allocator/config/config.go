@@ -10,10 +10,13 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
 	promconfig "github.com/prometheus/prometheus/config"
 	_ "github.com/prometheus/prometheus/discovery/install"
 	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
@@ -28,25 +31,78 @@ var (
 )
 
 const DefaultResyncTime = 5 * time.Minute
+const DefaultPrometheusCRDebounceInterval = time.Second
+const DefaultNamespaceSyncTimeout = 30 * time.Second
 const DefaultConfigFilePath string = "/conf/targetallocator.yaml"
 
 type Config struct {
 	LabelSelector map[string]string  `yaml:"label_selector,omitempty"`
 	Config        *promconfig.Config `yaml:"config"`
+
+	// ServiceMonitorSelector, PodMonitorSelector, ProbeSelector and NamespaceSelector restrict the Prometheus Operator
+	// custom resources the targets are read from. Like in the Prometheus Operator, a monitor selector which isn't set
+	// selects nothing and an empty one selects everything. A namespace selector which isn't set selects every namespace.
+	ServiceMonitorSelector *LabelSelector `yaml:"service_monitor_selector,omitempty"`
+	PodMonitorSelector     *LabelSelector `yaml:"pod_monitor_selector,omitempty"`
+	ProbeSelector          *LabelSelector `yaml:"probe_selector,omitempty"`
+	NamespaceSelector      *LabelSelector `yaml:"namespace_selector,omitempty"`
+}
+
+// LabelSelector is the equivalent of a Kubernetes label selector in the config file.
+type LabelSelector struct {
+	MatchLabels      map[string]string                 `yaml:"matchLabels,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `yaml:"matchExpressions,omitempty"`
+}
+
+// Selector converts the label selector, returning a selector matching nothing if it isn't set.
+func (s *LabelSelector) Selector() (labels.Selector, error) {
+	if s == nil {
+		return labels.Nothing(), nil
+	}
+	return metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      s.MatchLabels,
+		MatchExpressions: s.MatchExpressions,
+	})
 }
 
 type PrometheusCRWatcherConfig struct {
 	Enabled *bool
+	// DebounceInterval is how long changes of the Prometheus CRs are collected before the config is regenerated
+	DebounceInterval *time.Duration
+}
+
+// TLSServerConfig holds the files used to serve the endpoints over HTTPS.
+type TLSServerConfig struct {
+	CertFile *string
+	KeyFile  *string
+	// ClientCAFile enables client certificate authentication when set
+	ClientCAFile *string
+}
+
+// Enabled returns whether the endpoints should be served over HTTPS.
+func (c TLSServerConfig) Enabled() bool {
+	return c.CertFile != nil && c.KeyFile != nil && len(*c.CertFile) > 0 && len(*c.KeyFile) > 0
 }
 
 type CLIConfig struct {
-	ListenAddr     *string
-	ConfigFilePath *string
-	ClusterConfig  *rest.Config
+	ListenAddr         *string
+	ConfigFilePath     *string
+	AllocationStrategy *string
+	RebalanceLimit     *int
+	// CollectorWeightResource is the resource limit the collectors' weights are derived from, empty for equal weights
+	CollectorWeightResource *string
+	// CollectorNotReadyGracePeriod is how long a collector pod which stops being ready keeps its targets
+	CollectorNotReadyGracePeriod *time.Duration
+	ClusterConfig                *rest.Config
 	// KubeConfigFilePath empty if in cluster configuration is in use
 	KubeConfigFilePath string
 	RootLogger         logr.Logger
 	PromCRWatcherConf  PrometheusCRWatcherConfig
+	TLSConf            TLSServerConfig
+	// BearerTokenFile enables bearer token authentication when set
+	BearerTokenFile *string
+	// MetricsListenAddr serves the metrics on a separate listener over plain HTTP when set
+	MetricsListenAddr *string
 }
 
 func Load(file string) (Config, error) {
@@ -73,15 +129,33 @@ func ParseCLI() (CLIConfig, error) {
 	opts := zap.Options{}
 	opts.BindFlags(flag.CommandLine)
 	cLIConf := CLIConfig{
-		ListenAddr:     pflag.String("listen-addr", ":8080", "The address where this service serves."),
-		ConfigFilePath: pflag.String("config-file", DefaultConfigFilePath, "The path to the config file."),
+		ListenAddr:                   pflag.String("listen-addr", ":8080", "The address where this service serves."),
+		MetricsListenAddr:            pflag.String("metrics-listen-addr", "", "The address where the metrics are also served, over plain HTTP and without authentication. Useful when the endpoints require client certificates."),
+		ConfigFilePath:               pflag.String("config-file", DefaultConfigFilePath, "The path to the config file."),
+		AllocationStrategy:           pflag.String("allocation-strategy", allocation.DefaultStrategy, fmt.Sprintf("The strategy used to distribute targets among the collectors, one of %v.", allocation.GetRegisteredStrategyNames())),
+		RebalanceLimit:               pflag.Int("rebalance-limit", 0, "The maximum number of targets moved from the most loaded collectors to new ones when the set of collectors changes. 0 disables rebalancing."),
+		CollectorWeightResource:      pflag.String("collector-weight-resource", "", "The resource limit of the collector pods, cpu or memory, their targets are distributed in proportion to. When empty, all collectors get the same weight, unless overridden by the opentelemetry.io/target-allocator-weight annotation."),
+		CollectorNotReadyGracePeriod: pflag.Duration("collector-not-ready-grace-period", 0, "How long a collector pod which stops being ready keeps its targets before they are moved to the other collectors."),
 		PromCRWatcherConf: PrometheusCRWatcherConfig{
-			Enabled: pflag.Bool("enable-prometheus-cr-watcher", false, "Enable Prometheus CRs as target sources"),
+			Enabled:          pflag.Bool("enable-prometheus-cr-watcher", false, "Enable Prometheus CRs as target sources"),
+			DebounceInterval: pflag.Duration("prometheus-cr-debounce-interval", DefaultPrometheusCRDebounceInterval, "How long changes of the Prometheus CRs are collected before the scrape configs are generated again."),
 		},
+		TLSConf: TLSServerConfig{
+			CertFile:     pflag.String("tls-cert-file", "", "The path to the certificate used to serve over HTTPS. HTTPS is enabled when both the certificate and the key are set."),
+			KeyFile:      pflag.String("tls-key-file", "", "The path to the private key of the certificate used to serve over HTTPS."),
+			ClientCAFile: pflag.String("tls-client-ca-file", "", "The path to the CA certificate used to verify client certificates. When set, clients must present a valid certificate."),
+		},
+		BearerTokenFile: pflag.String("bearer-token-file", "", "The path to a file holding the token clients must send as 'Authorization: Bearer <token>'. When empty, no token is required."),
 	}
 	kubeconfigPath := pflag.String("kubeconfig-path", filepath.Join(homedir.HomeDir(), ".kube", "config"), "absolute path to the KubeconfigPath file")
 	pflag.Parse()
 
+	switch *cLIConf.CollectorWeightResource {
+	case "", "cpu", "memory":
+	default:
+		return CLIConfig{}, fmt.Errorf("invalid collector weight resource %q, must be cpu or memory", *cLIConf.CollectorWeightResource)
+	}
+
 	cLIConf.RootLogger = zap.New(zap.UseFlagOptions(&opts))
 	klog.SetLogger(cLIConf.RootLogger)
 	ctrl.SetLogger(cLIConf.RootLogger)
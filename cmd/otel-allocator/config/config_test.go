@@ -7,6 +7,8 @@ import (
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/discovery/file"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const testFile = "./testdata/config_test.yaml"
@@ -45,3 +47,34 @@ func TestConfigLoad(t *testing.T) {
 	assert.Equal(t, expectedFileSDConfig, actualFileSDConfig)
 	assert.Equal(t, expectedStaticSDConfig, actulaStaticSDConfig)
 }
+
+func TestConfigLoadSelectors(t *testing.T) {
+	cfg, err := Load("./testdata/selectors_test.yaml")
+	assert.NoError(t, err)
+
+	serviceMonitorSelector, err := cfg.ServiceMonitorSelector.Selector()
+	assert.NoError(t, err)
+	assert.Equal(t, "team=a", serviceMonitorSelector.String())
+
+	podMonitorSelector, err := cfg.PodMonitorSelector.Selector()
+	assert.NoError(t, err)
+	assert.True(t, podMonitorSelector.Matches(labels.Set{"team": "b"}))
+	assert.False(t, podMonitorSelector.Matches(labels.Set{"team": "c"}))
+
+	// an empty selector selects everything, an unset one nothing
+	namespaceSelector, err := cfg.NamespaceSelector.Selector()
+	assert.NoError(t, err)
+	assert.True(t, namespaceSelector.Empty())
+
+	var unset *LabelSelector
+	nothing, err := unset.Selector()
+	assert.NoError(t, err)
+	assert.False(t, nothing.Empty())
+	assert.False(t, nothing.Matches(labels.Set{}))
+}
+
+func TestInvalidSelector(t *testing.T) {
+	selector := &LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Unknown"}}}
+	_, err := selector.Selector()
+	assert.Error(t, err)
+}
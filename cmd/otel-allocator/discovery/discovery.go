@@ -2,14 +2,34 @@ package discovery
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-logr/logr"
 	"github.com/otel-allocator/allocation"
 	allocatorWatcher "github.com/otel-allocator/watcher"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+var (
+	targetsDiscovered = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_targets_discovered",
+		Help: "Number of targets discovered in the last service discovery sync.",
+	})
+	syncDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "opentelemetry_allocator_discovery_sync_duration_seconds",
+		Help: "Time it takes to process a service discovery sync, including the allocation of the targets.",
+	})
+	lastSync = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "opentelemetry_allocator_last_discovery_sync_timestamp_seconds",
+		Help: "Unix timestamp of the last service discovery sync.",
+	})
 )
 
 type Manager struct {
@@ -18,6 +38,9 @@ type Manager struct {
 	logger     log.Logger
 	close      chan struct{}
 	configsMap map[allocatorWatcher.EventSource]*config.Config
+	// tlsAssets holds the contents of the files referenced by the TLS configs of each source, indexed by path
+	tlsAssets map[allocatorWatcher.EventSource]map[string]string
+	configsMu sync.RWMutex
 }
 
 func NewManager(log logr.Logger, ctx context.Context, logger log.Logger, options ...func(*discovery.Manager)) *Manager {
@@ -34,20 +57,59 @@ func NewManager(log logr.Logger, ctx context.Context, logger log.Logger, options
 		logger:     logger,
 		close:      make(chan struct{}),
 		configsMap: make(map[allocatorWatcher.EventSource]*config.Config),
+		tlsAssets:  make(map[allocatorWatcher.EventSource]map[string]string),
 	}
 }
 
 func (m *Manager) ApplyConfig(source allocatorWatcher.EventSource, cfg *config.Config) error {
+	m.configsMu.Lock()
 	m.configsMap[source] = cfg
+	m.configsMu.Unlock()
 
 	discoveryCfg := make(map[string]discovery.Configs)
+	for jobName, scrapeConfig := range m.GetScrapeConfigs() {
+		discoveryCfg[jobName] = scrapeConfig.ServiceDiscoveryConfigs
+	}
+	return m.manager.ApplyConfig(discoveryCfg)
+}
+
+// SetTLSAssets sets the contents of the CA certificates, certificates and keys referenced by the scrape configs
+// of the given source, indexed by their path in the scrape configs.
+func (m *Manager) SetTLSAssets(source allocatorWatcher.EventSource, assets map[string]string) {
+	m.configsMu.Lock()
+	defer m.configsMu.Unlock()
+	m.tlsAssets[source] = assets
+}
 
+// GetTLSAssets returns the contents of the TLS assets of all sources, indexed by path.
+func (m *Manager) GetTLSAssets() map[string]string {
+	m.configsMu.RLock()
+	defer m.configsMu.RUnlock()
+
+	assets := make(map[string]string)
+	for _, sourceAssets := range m.tlsAssets {
+		for path, content := range sourceAssets {
+			assets[path] = content
+		}
+	}
+	return assets
+}
+
+// GetScrapeConfigs returns the scrape configs of all sources, indexed by job name.
+func (m *Manager) GetScrapeConfigs() map[string]*config.ScrapeConfig {
+	m.configsMu.RLock()
+	defer m.configsMu.RUnlock()
+
+	jobToScrapeConfig := make(map[string]*config.ScrapeConfig)
 	for _, value := range m.configsMap {
+		if value == nil {
+			continue
+		}
 		for _, scrapeConfig := range value.ScrapeConfigs {
-			discoveryCfg[scrapeConfig.JobName] = scrapeConfig.ServiceDiscoveryConfigs
+			jobToScrapeConfig[scrapeConfig.JobName] = scrapeConfig
 		}
 	}
-	return m.manager.ApplyConfig(discoveryCfg)
+	return jobToScrapeConfig
 }
 
 func (m *Manager) Watch(fn func(targets []allocation.TargetItem)) {
@@ -60,25 +122,62 @@ func (m *Manager) Watch(fn func(targets []allocation.TargetItem)) {
 				log.Info("Service Discovery watch event stopped: discovery manager closed")
 				return
 			case tsets := <-m.manager.SyncCh():
-				targets := []allocation.TargetItem{}
-
-				for jobName, tgs := range tsets {
-					for _, tg := range tgs {
-						for _, t := range tg.Targets {
-							targets = append(targets, allocation.TargetItem{
-								JobName:   jobName,
-								TargetURL: string(t[model.AddressLabel]),
-								Label:     tg.Labels,
-							})
-						}
-					}
-				}
+				start := time.Now()
+				lastSync.Set(float64(start.Unix()))
+				targets := TargetItems(tsets, m.GetScrapeConfigs())
+				targetsDiscovered.Set(float64(len(targets)))
 				fn(targets)
+				syncDuration.Observe(time.Since(start).Seconds())
 			}
 		}
 	}()
 }
 
+// TargetItems converts the target groups of every job, indexed by job name, into the targets of the Allocator. The
+// scrape interval of the targets is read from the scrape config of their job.
+func TargetItems(tsets map[string][]*targetgroup.Group, scrapeConfigs map[string]*config.ScrapeConfig) []allocation.TargetItem {
+	targets := []allocation.TargetItem{}
+	for jobName, tgs := range tsets {
+		var interval time.Duration
+		if scrapeConfig, ok := scrapeConfigs[jobName]; ok {
+			interval = time.Duration(scrapeConfig.ScrapeInterval)
+		}
+		for _, tg := range tgs {
+			for _, t := range tg.Targets {
+				targets = append(targets, allocation.TargetItem{
+					JobName:        jobName,
+					TargetURL:      string(t[model.AddressLabel]),
+					Label:          tg.Labels,
+					ScrapeInterval: interval,
+					NodeName:       nodeName(t, tg.Labels),
+				})
+			}
+		}
+	}
+	return targets
+}
+
 func (m *Manager) Close() {
 	close(m.close)
 }
+
+// nodeLabels are the labels of the Kubernetes service discovery holding the node of a target, by role:
+// pod, endpoints and node.
+var nodeLabels = []model.LabelName{
+	"__meta_kubernetes_pod_node_name",
+	"__meta_kubernetes_endpoint_node_name",
+	"__meta_kubernetes_node_name",
+}
+
+// nodeName returns the node of the target from its labels or the labels of its group, empty if unknown.
+func nodeName(target model.LabelSet, group model.LabelSet) string {
+	for _, name := range nodeLabels {
+		if node, ok := target[name]; ok {
+			return string(node)
+		}
+		if node, ok := group[name]; ok {
+			return string(node)
+		}
+	}
+	return ""
+}
@@ -8,9 +8,12 @@ import (
 	"testing"
 
 	gokitlog "github.com/go-kit/log"
+	"github.com/go-logr/logr"
 	"github.com/otel-allocator/allocation"
 	"github.com/otel-allocator/config"
+	allocatorWatcher "github.com/otel-allocator/watcher"
 	"github.com/prometheus/common/model"
+	promconfig "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,7 +29,7 @@ func TestMain(m *testing.M) {
 		fmt.Printf("failed to load config file: %v", err)
 		os.Exit(1)
 	}
-	manager = NewManager(context.Background(), gokitlog.NewNopLogger())
+	manager = NewManager(logr.Discard(), context.Background(), gokitlog.NewNopLogger())
 
 	results = make(chan []string)
 	manager.Watch(func(targets []allocation.TargetItem) {
@@ -45,7 +48,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestTargetDiscovery(t *testing.T) {
-	err := manager.ApplyConfig(cfg)
+	err := manager.ApplyConfig(allocatorWatcher.EventSourceConfigMap, cfg.Config)
 	assert.NoError(t, err)
 
 	gotTargets := <-results
@@ -70,7 +73,7 @@ func TestTargetUpdate(t *testing.T) {
 		},
 	}
 
-	err := manager.ApplyConfig(cfg)
+	err := manager.ApplyConfig(allocatorWatcher.EventSourceConfigMap, cfg.Config)
 	assert.NoError(t, err)
 
 	gotTargets := <-results
@@ -80,3 +83,24 @@ func TestTargetUpdate(t *testing.T) {
 	sort.Strings(wantTargets)
 	assert.Equal(t, gotTargets, wantTargets)
 }
+
+func TestGetScrapeConfigs(t *testing.T) {
+	crConfig := &promconfig.Config{
+		ScrapeConfigs: []*promconfig.ScrapeConfig{{JobName: "serviceMonitor/default/test/0"}},
+	}
+	err := manager.ApplyConfig(allocatorWatcher.EventSourcePrometheusCR, crConfig)
+	assert.NoError(t, err)
+	<-results
+
+	scrapeConfigs := manager.GetScrapeConfigs()
+	assert.Len(t, scrapeConfigs, 2)
+	assert.Contains(t, scrapeConfigs, "prometheus")
+	assert.Equal(t, crConfig.ScrapeConfigs[0], scrapeConfigs["serviceMonitor/default/test/0"])
+}
+
+func TestNodeName(t *testing.T) {
+	// endpoints targets hold the node of their pod, the groups of the node role hold the node
+	assert.Equal(t, "node-a", nodeName(model.LabelSet{"__meta_kubernetes_pod_node_name": "node-a"}, model.LabelSet{}))
+	assert.Equal(t, "node-b", nodeName(model.LabelSet{"__address__": "10.0.0.1:10250"}, model.LabelSet{"__meta_kubernetes_node_name": "node-b"}))
+	assert.Equal(t, "", nodeName(model.LabelSet{"__address__": "10.0.0.1:8080"}, model.LabelSet{"job": "static"}))
+}
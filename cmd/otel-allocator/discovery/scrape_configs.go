@@ -0,0 +1,106 @@
+package discovery
+
+import (
+	"encoding/json"
+
+	commonconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/config"
+	"gopkg.in/yaml.v2"
+	yaml2 "sigs.k8s.io/yaml"
+)
+
+// ScrapeConfigsJSON returns the scrape configs of all sources in the JSON format, indexed by job name.
+// The scrape configs only know how to marshal themselves to YAML, and they hide their credentials when doing so.
+// When revealSecrets is set, the credentials are added back, and the TLS assets, which only exist in memory and
+// are referenced by their path, are inlined in the ca, cert and key fields of the TLS configs.
+func (m *Manager) ScrapeConfigsJSON(revealSecrets bool) ([]byte, error) {
+	tlsAssets := m.GetTLSAssets()
+	configs := make(map[string]map[string]interface{})
+	for jobName, scrapeConfig := range m.GetScrapeConfigs() {
+		configBytes, err := yaml.Marshal(scrapeConfig)
+		if err != nil {
+			return nil, err
+		}
+		var converted map[string]interface{}
+		if err := yaml2.Unmarshal(configBytes, &converted); err != nil {
+			return nil, err
+		}
+		if revealSecrets {
+			revealCredentials(converted, scrapeConfig)
+			inlineTLSAssets(converted, tlsAssets)
+		}
+		configs[jobName] = converted
+	}
+	return json.Marshal(configs)
+}
+
+// revealCredentials replaces the hidden credentials of the converted scrape config with the actual ones.
+func revealCredentials(converted map[string]interface{}, scrapeConfig *config.ScrapeConfig) {
+	httpConfig := scrapeConfig.HTTPClientConfig
+	if httpConfig.BasicAuth != nil && len(httpConfig.BasicAuth.Password) > 0 {
+		setField(converted, "basic_auth", "password", string(httpConfig.BasicAuth.Password))
+	}
+	if httpConfig.Authorization != nil && len(httpConfig.Authorization.Credentials) > 0 {
+		setField(converted, "authorization", "credentials", string(httpConfig.Authorization.Credentials))
+	}
+	if httpConfig.OAuth2 != nil && len(httpConfig.OAuth2.ClientSecret) > 0 {
+		setField(converted, "oauth2", "client_secret", string(httpConfig.OAuth2.ClientSecret))
+	}
+	if len(httpConfig.BearerToken) > 0 {
+		converted["bearer_token"] = string(httpConfig.BearerToken)
+	}
+}
+
+// inlineTLSAssets replaces the paths of the TLS assets referenced by the TLS configs of the converted scrape config,
+// including the one of its OAuth2 section, with their contents.
+func inlineTLSAssets(converted map[string]interface{}, tlsAssets map[string]string) {
+	tlsConfigs := []interface{}{converted["tls_config"]}
+	if oauth2, ok := converted["oauth2"].(map[string]interface{}); ok {
+		tlsConfigs = append(tlsConfigs, oauth2["tls_config"])
+	}
+	for _, tlsConfig := range tlsConfigs {
+		values, ok := tlsConfig.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for fileField, field := range map[string]string{"ca_file": "ca", "cert_file": "cert", "key_file": "key"} {
+			path, _ := values[fileField].(string)
+			if content, ok := tlsAssets[path]; ok {
+				delete(values, fileField)
+				values[field] = content
+			}
+		}
+	}
+}
+
+// HasCredentials returns whether the scrape config holds credentials which are hidden unless revealed, or refers to
+// TLS assets, which are only inlined along with them.
+func HasCredentials(scrapeConfig *config.ScrapeConfig, tlsAssets map[string]string) bool {
+	httpConfig := scrapeConfig.HTTPClientConfig
+	if len(httpConfig.BearerToken) > 0 ||
+		(httpConfig.BasicAuth != nil && len(httpConfig.BasicAuth.Password) > 0) ||
+		(httpConfig.Authorization != nil && len(httpConfig.Authorization.Credentials) > 0) ||
+		(httpConfig.OAuth2 != nil && len(httpConfig.OAuth2.ClientSecret) > 0) {
+		return true
+	}
+
+	tlsConfigs := []commonconfig.TLSConfig{httpConfig.TLSConfig}
+	if httpConfig.OAuth2 != nil {
+		tlsConfigs = append(tlsConfigs, httpConfig.OAuth2.TLSConfig)
+	}
+	for _, tlsConfig := range tlsConfigs {
+		for _, path := range []string{tlsConfig.CAFile, tlsConfig.CertFile, tlsConfig.KeyFile} {
+			if _, ok := tlsAssets[path]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setField sets the field of the given section of the converted scrape config, if the section exists.
+func setField(converted map[string]interface{}, section, field string, value string) {
+	if values, ok := converted[section].(map[string]interface{}); ok {
+		values[field] = value
+	}
+}
@@ -0,0 +1,189 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-logr/logr"
+	allocatorWatcher "github.com/otel-allocator/watcher"
+	commonconfig "github.com/prometheus/common/config"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+	yaml2 "sigs.k8s.io/yaml"
+)
+
+func TestScrapeConfigsJSON(t *testing.T) {
+	m := NewManager(logr.Discard(), context.Background(), gokitlog.NewNopLogger())
+	defer m.Close()
+
+	crConfig := &promconfig.Config{
+		ScrapeConfigs: []*promconfig.ScrapeConfig{{
+			JobName: "serviceMonitor/default/test/0",
+			HTTPClientConfig: commonconfig.HTTPClientConfig{
+				BasicAuth: &commonconfig.BasicAuth{Username: "user", Password: "password"},
+				TLSConfig: commonconfig.TLSConfig{
+					CAFile:   "/etc/prometheus/certs/secret_default_tls_ca.crt",
+					CertFile: "/etc/prometheus/certs/secret_default_tls_tls.crt",
+					KeyFile:  "/etc/prometheus/certs/secret_default_tls_tls.key",
+				},
+			},
+		}},
+	}
+	require.NoError(t, m.ApplyConfig(allocatorWatcher.EventSourcePrometheusCR, crConfig))
+	m.SetTLSAssets(allocatorWatcher.EventSourcePrometheusCR, map[string]string{
+		"/etc/prometheus/certs/secret_default_tls_ca.crt":  "ca",
+		"/etc/prometheus/certs/secret_default_tls_tls.crt": "cert",
+		"/etc/prometheus/certs/secret_default_tls_tls.key": "key",
+	})
+
+	for _, tt := range []struct {
+		name              string
+		revealSecrets     bool
+		expectedBasicAuth map[string]interface{}
+		expectedTLSConfig map[string]interface{}
+	}{
+		{
+			name:              "hidden",
+			revealSecrets:     false,
+			expectedBasicAuth: map[string]interface{}{"username": "user", "password": "<secret>"},
+			expectedTLSConfig: map[string]interface{}{
+				"ca_file":              "/etc/prometheus/certs/secret_default_tls_ca.crt",
+				"cert_file":            "/etc/prometheus/certs/secret_default_tls_tls.crt",
+				"key_file":             "/etc/prometheus/certs/secret_default_tls_tls.key",
+				"insecure_skip_verify": false,
+			},
+		},
+		{
+			name:              "revealed",
+			revealSecrets:     true,
+			expectedBasicAuth: map[string]interface{}{"username": "user", "password": "password"},
+			expectedTLSConfig: map[string]interface{}{
+				"ca":                   "ca",
+				"cert":                 "cert",
+				"key":                  "key",
+				"insecure_skip_verify": false,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			configBytes, err := m.ScrapeConfigsJSON(tt.revealSecrets)
+			require.NoError(t, err)
+
+			var configs map[string]map[string]interface{}
+			require.NoError(t, json.Unmarshal(configBytes, &configs))
+			scrapeConfig := configs["serviceMonitor/default/test/0"]
+			assert.Equal(t, tt.expectedBasicAuth, scrapeConfig["basic_auth"])
+			assert.Equal(t, tt.expectedTLSConfig, scrapeConfig["tls_config"])
+		})
+	}
+}
+
+func TestScrapeConfigsJSONRoundTrip(t *testing.T) {
+	m := NewManager(logr.Discard(), context.Background(), gokitlog.NewNopLogger())
+	defer m.Close()
+
+	tlsConfig := commonconfig.TLSConfig{
+		CAFile:   "/etc/prometheus/certs/secret_default_tls_ca.crt",
+		CertFile: "/etc/prometheus/certs/secret_default_tls_tls.crt",
+		KeyFile:  "/etc/prometheus/certs/secret_default_tls_tls.key",
+	}
+	cfg, err := promconfig.Load(`
+scrape_configs:
+- job_name: basic-auth
+  basic_auth:
+    username: user
+    password: password
+  tls_config:
+    ca_file: /etc/prometheus/certs/secret_default_tls_ca.crt
+    cert_file: /etc/prometheus/certs/secret_default_tls_tls.crt
+    key_file: /etc/prometheus/certs/secret_default_tls_tls.key
+- job_name: authorization
+  authorization:
+    credentials: token
+- job_name: oauth2
+  oauth2:
+    client_id: client
+    client_secret: secret
+    token_url: https://auth.example.com/token
+    tls_config:
+      ca_file: /etc/prometheus/certs/secret_default_tls_ca.crt
+`, false, gokitlog.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, m.ApplyConfig(allocatorWatcher.EventSourcePrometheusCR, cfg))
+
+	configBytes, err := m.ScrapeConfigsJSON(true)
+	require.NoError(t, err)
+	var configs map[string]interface{}
+	require.NoError(t, json.Unmarshal(configBytes, &configs))
+
+	// the collectors unmarshal the scrape configs strictly, unknown fields included
+	for jobName, converted := range configs {
+		jsonConfig, err := json.Marshal(converted)
+		require.NoError(t, err)
+		yamlConfig, err := yaml2.JSONToYAML(jsonConfig)
+		require.NoError(t, err)
+		scrapeConfig := &promconfig.ScrapeConfig{}
+		require.NoError(t, yaml.UnmarshalStrict(yamlConfig, scrapeConfig), jobName)
+
+		httpConfig := scrapeConfig.HTTPClientConfig
+		switch jobName {
+		case "basic-auth":
+			assert.Equal(t, commonconfig.Secret("password"), httpConfig.BasicAuth.Password)
+			assert.Equal(t, tlsConfig, httpConfig.TLSConfig)
+		case "authorization":
+			assert.Equal(t, commonconfig.Secret("token"), httpConfig.Authorization.Credentials)
+		case "oauth2":
+			assert.Equal(t, commonconfig.Secret("secret"), httpConfig.OAuth2.ClientSecret)
+			assert.Equal(t, tlsConfig.CAFile, httpConfig.OAuth2.TLSConfig.CAFile)
+		}
+	}
+	assert.Len(t, configs, 3)
+}
+
+func TestHasCredentials(t *testing.T) {
+	tlsAssets := map[string]string{"/etc/prometheus/certs/secret_default_tls_ca.crt": "ca"}
+
+	for _, tt := range []struct {
+		desc       string
+		httpConfig commonconfig.HTTPClientConfig
+		expected   bool
+	}{
+		{
+			desc:       "no credentials",
+			httpConfig: commonconfig.HTTPClientConfig{},
+			expected:   false,
+		},
+		{
+			desc:       "basic auth",
+			httpConfig: commonconfig.HTTPClientConfig{BasicAuth: &commonconfig.BasicAuth{Username: "user", Password: "password"}},
+			expected:   true,
+		},
+		{
+			desc:       "TLS asset",
+			httpConfig: commonconfig.HTTPClientConfig{TLSConfig: commonconfig.TLSConfig{CAFile: "/etc/prometheus/certs/secret_default_tls_ca.crt"}},
+			expected:   true,
+		},
+		{
+			desc:       "file of the collector",
+			httpConfig: commonconfig.HTTPClientConfig{TLSConfig: commonconfig.TLSConfig{CAFile: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"}},
+			expected:   false,
+		},
+		{
+			desc: "TLS asset of the OAuth2 token URL",
+			httpConfig: commonconfig.HTTPClientConfig{OAuth2: &commonconfig.OAuth2{
+				ClientID:  "client",
+				TokenURL:  "https://auth.example.com/token",
+				TLSConfig: commonconfig.TLSConfig{CAFile: "/etc/prometheus/certs/secret_default_tls_ca.crt"},
+			}},
+			expected: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HasCredentials(&promconfig.ScrapeConfig{HTTPClientConfig: tt.httpConfig}, tlsAssets))
+		})
+	}
+}
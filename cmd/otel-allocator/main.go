@@ -7,21 +7,44 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
 	"syscall"
 
 	gokitlog "github.com/go-kit/log"
 	"github.com/go-logr/logr"
 	"github.com/gorilla/mux"
 	"github.com/otel-allocator/allocation"
+	"github.com/otel-allocator/auth"
 	"github.com/otel-allocator/collector"
 	"github.com/otel-allocator/config"
 	lbdiscovery "github.com/otel-allocator/discovery"
 	allocatorWatcher "github.com/otel-allocator/watcher"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	promconfig "github.com/prometheus/prometheus/config"
+	v1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// maxSeriesReportSize is the maximum size of the body of a series report, which holds a few tens of bytes per target.
+const maxSeriesReportSize = 4 << 20
+
 var (
-	setupLog = ctrl.Log.WithName("setup")
+	setupLog     = ctrl.Log.WithName("setup")
+	eventsMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_events_total",
+		Help: "Number of events received from the watchers.",
+	}, []string{"source"})
+	watcherErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_watcher_errors_total",
+		Help: "Number of errors reported by the watchers.",
+	})
+	promCRConfigsUnchanged = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "opentelemetry_allocator_prometheus_cr_configs_unchanged_total",
+		Help: "Number of configs generated from the Prometheus CRs which weren't applied, as they didn't change.",
+	})
 )
 
 func main() {
@@ -36,7 +59,13 @@ func main() {
 	ctx := context.Background()
 
 	log := ctrl.Log.WithName("allocator")
-	allocator := allocation.NewAllocator(log)
+	strategy, err := allocation.NewStrategy(*cliConf.AllocationStrategy)
+	if err != nil {
+		setupLog.Error(err, "Unable to initialize the allocation strategy")
+		os.Exit(1)
+	}
+	allocator := allocation.NewAllocator(log, strategy, allocation.WithRebalanceLimit(*cliConf.RebalanceLimit))
+	setupLog.Info("Using allocation strategy", "strategy", allocator.Strategy())
 	watcher, err := allocatorWatcher.NewWatcher(setupLog, cliConf, allocator)
 	if err != nil {
 		setupLog.Error(err, "Can't start the watchers")
@@ -44,6 +73,13 @@ func main() {
 	}
 	defer watcher.Close()
 
+	// the config is loaded once the file is watched, so that no change is missed
+	cfg, err := config.Load(*cliConf.ConfigFilePath)
+	if err != nil {
+		setupLog.Error(err, "Can't load the config")
+		os.Exit(1)
+	}
+
 	// creates a new discovery manager
 	discoveryManager := lbdiscovery.NewManager(log, ctx, gokitlog.NewNopLogger())
 	defer discoveryManager.Close()
@@ -51,10 +87,26 @@ func main() {
 		allocator.SetWaitingTargets(targets)
 		allocator.AllocateTargets()
 	})
+	if err := discoveryManager.ApplyConfig(allocatorWatcher.EventSourceConfigMap, cfg.Config); err != nil {
+		setupLog.Error(err, "Can't apply the config")
+		os.Exit(1)
+	}
+
+	collectorClient, err := newCollectorClient(log, cliConf)
+	if err != nil {
+		setupLog.Error(err, "Can't create the collector client")
+		os.Exit(1)
+	}
+	setCollectors := func(collectors map[string]allocation.CollectorInfo) {
+		allocator.SetCollectorInfo(collectors)
+		allocator.ReallocateCollectors()
+	}
+	collectorClient.Watch(ctx, cfg.LabelSelector, setCollectors)
 
 	srv, err := newServer(log, allocator, discoveryManager, cliConf)
 	if err != nil {
 		setupLog.Error(err, "Can't start the server")
+		os.Exit(1)
 	}
 
 	interrupts := make(chan os.Signal, 1)
@@ -66,137 +118,247 @@ func main() {
 		}
 	}()
 
+	// the hash of the config generated from the Prometheus CRs which is currently applied
+	var appliedPromCRConfigHash string
 	for {
 		select {
 		case <-interrupts:
+			collectorClient.Close()
 			if err := srv.Shutdown(ctx); err != nil {
 				setupLog.Error(err, "Error on server shutdown")
 				os.Exit(1)
 			}
 			os.Exit(0)
 		case event := <-watcher.Events:
+			eventsMetric.WithLabelValues(event.Source.String()).Inc()
 			switch event.Source {
 			case allocatorWatcher.EventSourceConfigMap:
 				setupLog.Info("ConfigMap updated!")
-				// Restart the server to pickup the new config.
-				if err := srv.Shutdown(ctx); err != nil {
-					setupLog.Error(err, "Cannot shutdown the server")
-				}
-				srv, err = newServer(log, allocator, discoveryManager, cliConf)
+				// the new config is applied in place, the server keeps serving the current allocation meanwhile
+				newCfg, err := config.Load(*cliConf.ConfigFilePath)
 				if err != nil {
-					setupLog.Error(err, "Error restarting the server with new config")
+					setupLog.Error(err, "Can't load the updated config, keeping the current one")
+					continue
+				}
+				// the watchers validate their selectors first, so that an invalid config isn't applied at all
+				if err := watcher.ApplyConfig(newCfg); err != nil {
+					setupLog.Error(err, "Can't apply the updated config, keeping the current one")
+					continue
+				}
+				if err := discoveryManager.ApplyConfig(allocatorWatcher.EventSourceConfigMap, newCfg.Config); err != nil {
+					setupLog.Error(err, "Can't apply the updated config")
+					continue
 				}
-				go func() {
-					if err := srv.Start(); err != http.ErrServerClosed {
-						setupLog.Error(err, "Can't restart the server")
-					}
-				}()
+				// only a different label selector requires listing the collectors again
+				if !reflect.DeepEqual(newCfg.LabelSelector, cfg.LabelSelector) {
+					setupLog.Info("Collector label selector changed", "labelSelector", newCfg.LabelSelector)
+					collectorClient.Watch(ctx, newCfg.LabelSelector, setCollectors)
+				}
+				cfg = newCfg
 
 			case allocatorWatcher.EventSourcePrometheusCR:
 				setupLog.Info("PrometheusCRs changed")
-				promConfig, err := interface{}(*event.Watcher).(*allocatorWatcher.PrometheusCRWatcher).CreatePromConfig(cliConf.KubeConfigFilePath)
+				promWatcher := interface{}(*event.Watcher).(*allocatorWatcher.PrometheusCRWatcher)
+				promConfig, err := promWatcher.CreatePromConfig(cliConf.KubeConfigFilePath)
 				if err != nil {
+					// keep the jobs of the last config rather than dropping them all
 					setupLog.Error(err, "failed to compile Prometheus config")
+					continue
+				}
+				if promWatcher.ConfigHash() == appliedPromCRConfigHash {
+					promCRConfigsUnchanged.Inc()
+					setupLog.V(1).Info("Prometheus config unchanged, not applying it")
+					continue
 				}
+				discoveryManager.SetTLSAssets(allocatorWatcher.EventSourcePrometheusCR, promWatcher.TLSAssets())
 				err = discoveryManager.ApplyConfig(allocatorWatcher.EventSourcePrometheusCR, promConfig)
 				if err != nil {
 					setupLog.Error(err, "failed to apply Prometheus config")
+					continue
+				}
+				appliedPromCRConfigHash = promWatcher.ConfigHash()
+				if !srv.revealSecrets {
+					warnHiddenCredentials(promConfig, promWatcher.TLSAssets())
 				}
 			}
 		case err := <-watcher.Errors:
+			watcherErrors.Inc()
 			setupLog.Error(err, "Watcher error")
 		}
 	}
 }
 
+// warnHiddenCredentials reports the jobs whose credentials aren't served, so that the collectors can't scrape their
+// targets, because the endpoints aren't secured.
+func warnHiddenCredentials(promConfig *promconfig.Config, tlsAssets map[string]string) {
+	for _, scrapeConfig := range promConfig.ScrapeConfigs {
+		if lbdiscovery.HasCredentials(scrapeConfig, tlsAssets) {
+			setupLog.Info("The credentials of the job are hidden from the collectors, serve the endpoints over HTTPS with client certificates or a bearer token to reveal them", "job", scrapeConfig.JobName)
+		}
+	}
+}
+
 type server struct {
 	logger           logr.Logger
 	allocator        *allocation.Allocator
 	discoveryManager *lbdiscovery.Manager
-	k8sClient        *collector.Client
 	server           *http.Server
+	// metricsServer serves the metrics on their own listener, nil unless configured
+	metricsServer *http.Server
+	// revealSecrets is set when the endpoints are only served to authenticated clients over HTTPS,
+	// the credentials of the scrape configs are hidden otherwise
+	revealSecrets bool
 }
 
 func newServer(log logr.Logger, allocator *allocation.Allocator, discoveryManager *lbdiscovery.Manager, cliConf config.CLIConfig) (*server, error) {
-	k8sclient, err := configureFileDiscovery(log, allocator, discoveryManager, context.Background(), cliConf)
-	if err != nil {
-		return nil, err
-	}
+	var err error
 	s := &server{
 		logger:           log,
 		allocator:        allocator,
 		discoveryManager: discoveryManager,
-		k8sClient:        k8sclient,
+		revealSecrets:    cliConf.TLSConf.Enabled() && (len(*cliConf.TLSConf.ClientCAFile) > 0 || len(*cliConf.BearerTokenFile) > 0),
 	}
 	router := mux.NewRouter().UseEncodedPath()
 	router.HandleFunc("/jobs", s.JobHandler).Methods("GET")
 	router.HandleFunc("/jobs/{job_id}/targets", s.TargetsHandler).Methods("GET")
-	s.server = &http.Server{Addr: *cliConf.ListenAddr, Handler: router}
-	return s, nil
-}
+	router.HandleFunc("/scrape_configs", s.ScrapeConfigsHandler).Methods("GET")
+	router.HandleFunc("/series", s.SeriesHandler).Methods("POST")
+	router.HandleFunc("/collectors", s.CollectorsHandler).Methods("GET")
+	router.HandleFunc("/collectors/{collector_id}/targets", s.CollectorTargetsHandler).Methods("GET")
+	router.HandleFunc("/debug/allocation", s.AllocationDebugHandler).Methods("GET")
 
-func configureFileDiscovery(log logr.Logger, allocator *allocation.Allocator, discoveryManager *lbdiscovery.Manager, ctx context.Context, cliConfig config.CLIConfig) (*collector.Client, error) {
-	cfg, err := config.Load(*cliConfig.ConfigFilePath)
-	if err != nil {
-		return nil, err
+	var handler http.Handler = router
+	if len(*cliConf.BearerTokenFile) > 0 {
+		handler, err = auth.BearerTokenHandler(*cliConf.BearerTokenFile, router)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	k8sClient, err := collector.NewClient(log, cliConfig.ClusterConfig)
-	if err != nil {
-		return nil, err
+	s.server = &http.Server{Addr: *cliConf.ListenAddr, Handler: withMetrics(handler)}
+	if len(*cliConf.MetricsListenAddr) > 0 {
+		s.metricsServer = &http.Server{Addr: *cliConf.MetricsListenAddr, Handler: withMetrics(http.NotFoundHandler())}
 	}
+	if cliConf.TLSConf.Enabled() {
+		s.server.TLSConfig, err = auth.NewTLSConfig(cliConf.TLSConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
 
-	// returns the list of targets
-	if err := discoveryManager.ApplyConfig(allocatorWatcher.EventSourceConfigMap, cfg.Config); err != nil {
-		return nil, err
+// newCollectorClient creates the client watching the collector pods, which weighs them as configured.
+func newCollectorClient(log logr.Logger, cliConfig config.CLIConfig) (*collector.Client, error) {
+	var options []func(*collector.Client)
+	if len(*cliConfig.CollectorWeightResource) > 0 {
+		options = append(options, collector.WithWeightResource(v1.ResourceName(*cliConfig.CollectorWeightResource)))
 	}
+	if *cliConfig.CollectorNotReadyGracePeriod > 0 {
+		options = append(options, collector.WithNotReadyGracePeriod(*cliConfig.CollectorNotReadyGracePeriod))
+	}
+	return collector.NewClient(log, cliConfig.ClusterConfig, options...)
+}
 
-	k8sClient.Watch(ctx, cfg.LabelSelector, func(collectors []string) {
-		allocator.SetCollectors(collectors)
-		allocator.ReallocateCollectors()
+// withMetrics serves the metrics at /metrics, and the other paths with the handler. The metrics don't require the
+// token of the collectors, so that they can be scraped like those of any other component.
+func withMetrics(next http.Handler) http.Handler {
+	metrics := promhttp.Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" && r.Method == http.MethodGet {
+			metrics.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
-	return k8sClient, nil
 }
 
 func (s *server) Start() error {
 	setupLog.Info("Starting server...")
+	if s.metricsServer != nil {
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); err != http.ErrServerClosed {
+				setupLog.Error(err, "Can't start the metrics server")
+			}
+		}()
+	}
+	if s.server.TLSConfig != nil {
+		// the certificate is provided by the TLS config, so that it can be reloaded
+		return s.server.ListenAndServeTLS("", "")
+	}
 	return s.server.ListenAndServe()
 }
 
 func (s *server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Shutting down server...")
-	s.k8sClient.Close()
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
 	return s.server.Shutdown(ctx)
 }
 
 func (s *server) JobHandler(w http.ResponseWriter, r *http.Request) {
-	displayData := make(map[string]allocation.LinkJSON)
-	for _, v := range s.allocator.TargetItems {
-		displayData[v.JobName] = allocation.LinkJSON{v.Link.Link}
+	jsonHandler(w, r, s.allocator.Snapshot().Jobs())
+}
+
+// CollectorsHandler returns the weight, number of targets and cost of every collector.
+func (s *server) CollectorsHandler(w http.ResponseWriter, r *http.Request) {
+	jsonHandler(w, r, allocation.GetCollectors(s.allocator.Snapshot()))
+}
+
+// AllocationDebugHandler returns which collector every target is assigned to and why, along with the recent
+// assignments. The targets can be restricted with the job_id and target query parameters.
+func (s *server) AllocationDebugHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	jsonHandler(w, r, allocation.GetAllocationDebug(s.allocator.Snapshot(), q.Get("job_id"), q.Get("target")))
+}
+
+// ScrapeConfigsHandler returns the scrape configs of all jobs, from the config file as well as from the Prometheus CRs,
+// so that the collectors can keep their list of jobs in sync with the TargetAllocator.
+func (s *server) ScrapeConfigsHandler(w http.ResponseWriter, r *http.Request) {
+	jsonConfig, err := s.discoveryManager.ScrapeConfigsJSON(s.revealSecrets)
+	if err != nil {
+		errorHandler(err, w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(jsonConfig); err != nil {
+		s.logger.Error(err, "failed to write the scrape configs")
+	}
+}
+
+// SeriesHandler accepts the number of series the collectors observed for their targets, which are
+// taken into account by the cost of the targets.
+func (s *server) SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	var reports []allocation.SeriesReport
+	body := http.MaxBytesReader(w, r.Body, maxSeriesReportSize)
+	if err := json.NewDecoder(body).Decode(&reports); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
 	}
-	jsonHandler(w, r, displayData)
+	if rejected := s.allocator.ReportSeries(reports); rejected > 0 {
+		s.logger.V(1).Info("Ignored series reports of targets not allocated to their collector", "rejected", rejected)
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *server) TargetsHandler(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()["collector_id"]
-
-	var compareMap = make(map[string][]allocation.TargetItem) // CollectorName+jobName -> TargetItem
-	for _, v := range s.allocator.TargetItems {
-		compareMap[v.Collector.Name+v.JobName] = append(compareMap[v.Collector.Name+v.JobName], *v)
-	}
+	snapshot := s.allocator.Snapshot()
 	params := mux.Vars(r)
+	jobId, err := url.QueryUnescape(params["job_id"])
+	if err != nil {
+		errorHandler(err, w, r)
+		return
+	}
 
 	if len(q) == 0 {
-		jobId, err := url.QueryUnescape(params["job_id"])
-		if err != nil {
-			errorHandler(err, w, r)
-			return
-		}
-		displayData := allocation.GetAllTargetsByJob(jobId, compareMap, s.allocator)
+		displayData := allocation.GetAllTargetsByJob(jobId, snapshot)
 		jsonHandler(w, r, displayData)
 
 	} else {
-		tgs := allocation.GetAllTargetsByCollectorAndJob(q[0], params["job_id"], compareMap, s.allocator)
+		tgs := allocation.GetAllTargetsByCollectorAndJob(q[0], jobId, snapshot)
 		// Displays empty list if nothing matches
 		if len(tgs) == 0 {
 			jsonHandler(w, r, []interface{}{})
@@ -206,6 +368,44 @@ func (s *server) TargetsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// CollectorTargetsHandler returns the target groups of every job of one collector, indexed by job name, so that a
+// client handling all the jobs of a collector polls a single endpoint rather than one per job. The prometheus receiver
+// can't, as it discovers the targets of each job separately. The response carries an ETag, and polls sending it back
+// in If-None-Match get an empty 304 Not Modified response as long as the targets of the collector didn't change.
+func (s *server) CollectorTargetsHandler(w http.ResponseWriter, r *http.Request) {
+	collectorId, err := url.QueryUnescape(mux.Vars(r)["collector_id"])
+	if err != nil {
+		errorHandler(err, w, r)
+		return
+	}
+	targets, err := s.allocator.Snapshot().CollectorTargets(collectorId)
+	if err != nil {
+		errorHandler(err, w, r)
+		return
+	}
+
+	w.Header().Set("ETag", targets.ETag)
+	if etagMatches(r.Header.Get("If-None-Match"), targets.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(targets.Body); err != nil {
+		s.logger.Error(err, "failed to write the targets", "collector", collectorId)
+	}
+}
+
+// etagMatches returns whether the If-None-Match header lists the ETag, using the weak comparison of RFC 7232.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func errorHandler(err error, w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(500)
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
+	"github.com/otel-allocator/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsWithoutToken(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("secret"), 0600))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	secured, err := auth.BearerTokenHandler(tokenFile, next)
+	require.NoError(t, err)
+	handler := withMetrics(secured)
+
+	for _, tt := range []struct {
+		desc   string
+		path   string
+		status int
+	}{
+		{desc: "metrics", path: "/metrics", status: http.StatusOK},
+		{desc: "other endpoints", path: "/scrape_configs", status: http.StatusUnauthorized},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
+
+func TestSeriesHandlerBodyLimit(t *testing.T) {
+	strategy, err := allocation.NewStrategy(allocation.DefaultStrategy)
+	require.NoError(t, err)
+	s := &server{logger: logr.Discard(), allocator: allocation.NewAllocator(logr.Discard(), strategy)}
+
+	for _, tt := range []struct {
+		desc   string
+		body   string
+		status int
+	}{
+		{desc: "report", body: `[{"collector_id": "col-1", "job_name": "job", "target": "10.0.0.1:8080", "series": 10}]`, status: http.StatusNoContent},
+		{desc: "oversized report", body: "[" + strings.Repeat(" ", maxSeriesReportSize) + "]", status: http.StatusBadRequest},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			s.SeriesHandler(recorder, httptest.NewRequest(http.MethodPost, "/series", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, recorder.Code)
+		})
+	}
+}
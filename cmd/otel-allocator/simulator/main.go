@@ -0,0 +1,80 @@
+// The simulator allocates targets offline, with the same Allocator as the TargetAllocator, and reports how they're
+// distributed among the collectors and how many of them move when a collector is added or removed. It's meant to
+// evaluate allocation strategies and their settings before rolling them out.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/otel-allocator/allocation"
+	"github.com/otel-allocator/config"
+	"github.com/spf13/pflag"
+)
+
+type options struct {
+	configFile     string
+	targetsFile    string
+	targetsJob     string
+	collectors     []string
+	newCollector   string
+	strategy       string
+	rebalanceLimit int
+}
+
+func main() {
+	opts := options{}
+	pflag.StringVar(&opts.configFile, "config-file", "", "The path to a TargetAllocator config file, the targets of the static and file service discovery configs of its jobs are allocated.")
+	pflag.StringVar(&opts.targetsFile, "targets-file", "", "The path to a file of targets in the file service discovery format, JSON or YAML, allocated in addition to the ones of the config file.")
+	pflag.StringVar(&opts.targetsJob, "targets-job", "simulated", "The job the targets of the targets file belong to.")
+	pflag.StringSliceVar(&opts.collectors, "collectors", nil, "The names of the collectors the targets are allocated to.")
+	pflag.StringVar(&opts.newCollector, "new-collector", "new-collector", "The name of the collector added to measure how many targets move when a collector is added.")
+	pflag.StringVar(&opts.strategy, "allocation-strategy", allocation.DefaultStrategy, fmt.Sprintf("The strategy used to distribute targets among the collectors, one of %v.", allocation.GetRegisteredStrategyNames()))
+	pflag.IntVar(&opts.rebalanceLimit, "rebalance-limit", 0, "The maximum number of targets moved from the most loaded collectors to new ones when the set of collectors changes. 0 disables rebalancing.")
+	pflag.Parse()
+
+	if err := run(os.Stdout, opts); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(out io.Writer, opts options) error {
+	if len(opts.collectors) == 0 {
+		return errors.New("no collectors given, set them with --collectors")
+	}
+
+	var cfg config.Config
+	if len(opts.configFile) > 0 {
+		var err error
+		if cfg, err = config.Load(opts.configFile); err != nil {
+			return err
+		}
+	}
+	targets, skipped, err := loadTargets(cfg, filepath.Dir(opts.configFile), opts.targetsFile, opts.targetsJob)
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(out, "Skipped the service discoveries of the jobs %s, only static and file service discoveries can be simulated.\n\n", strings.Join(skipped, ", "))
+	}
+	if len(targets) == 0 {
+		return errors.New("no targets found, set them with --config-file or --targets-file")
+	}
+
+	sim := simulation{
+		strategy:       opts.strategy,
+		rebalanceLimit: opts.rebalanceLimit,
+		collectors:     opts.collectors,
+		targets:        targets,
+	}
+	r, err := sim.run(opts.newCollector)
+	if err != nil {
+		return err
+	}
+	return r.print(out)
+}
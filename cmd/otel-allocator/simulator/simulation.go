@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/go-logr/logr"
+	"github.com/otel-allocator/allocation"
+)
+
+// simulation allocates targets offline, with the same Allocator as the TargetAllocator.
+type simulation struct {
+	strategy       string
+	rebalanceLimit int
+	collectors     []string
+	targets        []allocation.TargetItem
+}
+
+// report describes the allocation of the targets among the collectors, and how many targets move when the set of
+// collectors changes.
+type report struct {
+	strategy   string
+	targets    int
+	unassigned int
+	// collectors is sorted by name
+	collectors  []collectorReport
+	targetStats stats
+	costStats   stats
+
+	// movedOnAddition is the number of targets moving when newCollector is added
+	newCollector    string
+	movedOnAddition int
+}
+
+type collectorReport struct {
+	name    string
+	targets int
+	cost    float64
+	// movedOnRemoval is the number of targets moving when the collector is removed, or becoming unassigned when
+	// it's the last one
+	movedOnRemoval int
+}
+
+// stats summarizes the distribution of a value among the collectors.
+type stats struct {
+	min, max, mean, stddev float64
+}
+
+// skew returns how much the most loaded collector exceeds the mean, 1 for an even distribution.
+func (s stats) skew() float64 {
+	if s.mean == 0 {
+		return 0
+	}
+	return s.max / s.mean
+}
+
+func newStats(values []float64) stats {
+	if len(values) == 0 {
+		return stats{}
+	}
+	s := stats{min: math.Inf(1), max: math.Inf(-1)}
+	for _, v := range values {
+		s.min = math.Min(s.min, v)
+		s.max = math.Max(s.max, v)
+		s.mean += v
+	}
+	s.mean /= float64(len(values))
+	for _, v := range values {
+		s.stddev += (v - s.mean) * (v - s.mean)
+	}
+	s.stddev = math.Sqrt(s.stddev / float64(len(values)))
+	return s
+}
+
+// allocate returns an allocator which allocated the targets to the given collectors.
+func (s simulation) allocate(collectors []string) (*allocation.Allocator, error) {
+	strategy, err := allocation.NewStrategy(s.strategy)
+	if err != nil {
+		return nil, err
+	}
+	allocator := allocation.NewAllocator(logr.Discard(), strategy,
+		allocation.WithRebalanceLimit(s.rebalanceLimit), allocation.WithHistorySize(0))
+	allocator.SetCollectors(collectors)
+	allocator.SetWaitingTargets(s.targets)
+	allocator.AllocateTargets()
+	return allocator, nil
+}
+
+// moved returns how many targets move to another collector, or lose their collector, when the collectors of the
+// simulation are replaced by the given ones.
+func (s simulation) moved(collectors []string) (int, error) {
+	allocator, err := s.allocate(s.collectors)
+	if err != nil {
+		return 0, err
+	}
+	before := assignments(allocator.Snapshot())
+	allocator.SetCollectors(collectors)
+	allocator.ReallocateCollectors()
+	after := assignments(allocator.Snapshot())
+
+	moved := 0
+	for k, col := range before {
+		if after[k] != col {
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// run allocates the targets and measures how many of them move when a collector is added or removed.
+func (s simulation) run(newCollector string) (report, error) {
+	allocator, err := s.allocate(s.collectors)
+	if err != nil {
+		return report{}, err
+	}
+	snapshot := allocator.Snapshot()
+
+	keys := make(map[string]struct{}, len(s.targets))
+	for _, t := range s.targets {
+		keys[t.JobName+t.TargetURL] = struct{}{}
+	}
+	r := report{
+		strategy:     allocator.Strategy(),
+		targets:      len(keys),
+		unassigned:   len(keys) - len(snapshot.TargetItems()),
+		newCollector: newCollector,
+	}
+
+	var targets, costs []float64
+	for name, col := range allocation.GetCollectors(snapshot) {
+		r.collectors = append(r.collectors, collectorReport{name: name, targets: col.NumTargets, cost: col.Cost})
+		targets = append(targets, float64(col.NumTargets))
+		costs = append(costs, col.Cost)
+	}
+	sort.Slice(r.collectors, func(i, j int) bool {
+		return r.collectors[i].name < r.collectors[j].name
+	})
+	r.targetStats = newStats(targets)
+	r.costStats = newStats(costs)
+
+	for i := range r.collectors {
+		var remaining []string
+		for _, col := range r.collectors {
+			if col.name != r.collectors[i].name {
+				remaining = append(remaining, col.name)
+			}
+		}
+		if r.collectors[i].movedOnRemoval, err = s.moved(remaining); err != nil {
+			return report{}, err
+		}
+	}
+	r.movedOnAddition, err = s.moved(append(append([]string(nil), s.collectors...), newCollector))
+	if err != nil {
+		return report{}, err
+	}
+	return r, nil
+}
+
+func assignments(snapshot *allocation.Snapshot) map[string]string {
+	result := make(map[string]string, len(snapshot.TargetItems()))
+	for k, item := range snapshot.TargetItems() {
+		result[k] = item.Collector.Name
+	}
+	return result
+}
+
+// print writes the report as tables.
+func (r report) print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Strategy:\t%s\n", r.strategy)
+	fmt.Fprintf(w, "Targets:\t%d (%d unassigned)\n", r.targets, r.unassigned)
+	fmt.Fprintf(w, "Collectors:\t%d\n\n", len(r.collectors))
+
+	fmt.Fprintln(w, "COLLECTOR\tTARGETS\tSHARE\tCOST\tMOVED IF REMOVED")
+	for _, col := range r.collectors {
+		moved := fmt.Sprintf("%d (%s)", col.movedOnRemoval, percentage(col.movedOnRemoval, r.targets))
+		if len(r.collectors) == 1 {
+			moved += " unassigned"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.2f\t%s\n", col.name, col.targets, percentage(col.targets, r.targets), col.cost, moved)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "PER COLLECTOR\tMIN\tMAX\tMEAN\tSTDDEV\tSKEW (MAX/MEAN)")
+	for _, row := range []struct {
+		name  string
+		stats stats
+	}{{"targets", r.targetStats}, {"cost", r.costStats}} {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\n", row.name, row.stats.min, row.stats.max, row.stats.mean, row.stats.stddev, row.stats.skew())
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Adding the collector %s moves %d targets (%s).\n", r.newCollector, r.movedOnAddition, percentage(r.movedOnAddition, r.targets))
+	return w.Flush()
+}
+
+func percentage(n int, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/otel-allocator/allocation"
+	"github.com/otel-allocator/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTargets(n int) []allocation.TargetItem {
+	var targets []allocation.TargetItem
+	for i := 0; i < n; i++ {
+		targets = append(targets, allocation.TargetItem{JobName: "job", TargetURL: fmt.Sprintf("10.0.0.%d:8080", i), Label: model.LabelSet{}})
+	}
+	return targets
+}
+
+func TestLoadTargets(t *testing.T) {
+	cfg, err := config.Load("testdata/targetallocator.yaml")
+	require.NoError(t, err)
+
+	targets, skipped, err := loadTargets(cfg, "testdata", "testdata/targets.yaml", "extra")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes"}, skipped)
+
+	byJob := make(map[string][]allocation.TargetItem)
+	for _, target := range targets {
+		byJob[target.JobName] = append(byJob[target.JobName], target)
+	}
+	assert.Len(t, byJob["static"], 2)
+	assert.Len(t, byJob["file"], 3)
+	assert.Len(t, byJob["extra"], 3)
+	assert.Equal(t, 10*time.Second, byJob["static"][0].ScrapeInterval)
+	assert.Equal(t, model.LabelValue("b"), byJob["extra"][0].Label["team"])
+}
+
+func TestSimulationReport(t *testing.T) {
+	sim := simulation{
+		strategy:   allocation.LeastWeightedStrategy,
+		collectors: []string{"col-1", "col-2", "col-3", "col-4"},
+		targets:    makeTargets(100),
+	}
+	r, err := sim.run("col-5")
+	require.NoError(t, err)
+
+	assert.Equal(t, 100, r.targets)
+	assert.Zero(t, r.unassigned)
+	require.Len(t, r.collectors, 4)
+	assert.Equal(t, "col-1", r.collectors[0].name)
+	assert.Equal(t, stats{min: 25, max: 25, mean: 25}, r.targetStats)
+	assert.Equal(t, 1.0, r.targetStats.skew())
+	for _, col := range r.collectors {
+		// only the targets of the removed collector move
+		assert.Equal(t, 25, col.movedOnRemoval)
+	}
+	// new collectors only get new targets without rebalancing
+	assert.Zero(t, r.movedOnAddition)
+
+	sim.rebalanceLimit = 10
+	r, err = sim.run("col-5")
+	require.NoError(t, err)
+	assert.Equal(t, 10, r.movedOnAddition)
+
+	var out bytes.Buffer
+	require.NoError(t, r.print(&out))
+	assert.Contains(t, out.String(), "Adding the collector col-5 moves 10 targets (10.0%).")
+}
+
+func TestSimulationSingleCollector(t *testing.T) {
+	sim := simulation{
+		strategy:   allocation.ConsistentHashingStrategy,
+		collectors: []string{"col-1"},
+		targets:    makeTargets(100),
+	}
+	r, err := sim.run("col-2")
+	require.NoError(t, err)
+	// removing the last collector leaves every target unassigned
+	assert.Equal(t, 100, r.collectors[0].movedOnRemoval)
+	assert.Greater(t, r.movedOnAddition, 0)
+	assert.Less(t, r.movedOnAddition, 100)
+
+	var out bytes.Buffer
+	require.NoError(t, r.print(&out))
+	assert.Contains(t, out.String(), "100 (100.0%) unassigned")
+}
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	err := run(&out, options{configFile: "testdata/targetallocator.yaml", collectors: []string{"col-1", "col-2"}, newCollector: "col-3", strategy: allocation.ConsistentHashingStrategy})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Skipped the service discoveries of the jobs kubernetes")
+	assert.Contains(t, out.String(), "5 (0 unassigned)")
+
+	err = run(&out, options{configFile: "testdata/targetallocator.yaml", strategy: allocation.ConsistentHashingStrategy})
+	assert.EqualError(t, err, "no collectors given, set them with --collectors")
+
+	err = run(&out, options{collectors: []string{"col-1"}, strategy: allocation.ConsistentHashingStrategy})
+	assert.EqualError(t, err, "no targets found, set them with --config-file or --targets-file")
+}
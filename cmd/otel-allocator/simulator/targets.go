@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/otel-allocator/allocation"
+	"github.com/otel-allocator/config"
+	lbdiscovery "github.com/otel-allocator/discovery"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/file"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"gopkg.in/yaml.v2"
+)
+
+// loadTargets returns the targets of the static and file service discovery configs of the jobs in the config, and
+// the ones of the targets file, which belong to the given job. Like in Prometheus, the files of the file service
+// discoveries are relative to configDir, the directory of the config file. It also returns the jobs which use other
+// service discoveries, as their targets can't be discovered offline.
+func loadTargets(cfg config.Config, configDir string, targetsFile string, targetsJob string) ([]allocation.TargetItem, []string, error) {
+	tsets := make(map[string][]*targetgroup.Group)
+	scrapeConfigs := make(map[string]*promconfig.ScrapeConfig)
+	var skipped []string
+
+	if cfg.Config != nil {
+		for _, scrapeConfig := range cfg.Config.ScrapeConfigs {
+			scrapeConfigs[scrapeConfig.JobName] = scrapeConfig
+			for _, sdConfig := range scrapeConfig.ServiceDiscoveryConfigs {
+				switch c := sdConfig.(type) {
+				case discovery.StaticConfig:
+					tsets[scrapeConfig.JobName] = append(tsets[scrapeConfig.JobName], c...)
+				case *file.SDConfig:
+					groups, err := readFileSDConfig(c, configDir)
+					if err != nil {
+						return nil, nil, fmt.Errorf("job %s: %w", scrapeConfig.JobName, err)
+					}
+					tsets[scrapeConfig.JobName] = append(tsets[scrapeConfig.JobName], groups...)
+				default:
+					if len(skipped) == 0 || skipped[len(skipped)-1] != scrapeConfig.JobName {
+						skipped = append(skipped, scrapeConfig.JobName)
+					}
+				}
+			}
+		}
+	}
+
+	if len(targetsFile) > 0 {
+		groups, err := readTargetGroups(targetsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tsets[targetsJob] = append(tsets[targetsJob], groups...)
+	}
+	return lbdiscovery.TargetItems(tsets, scrapeConfigs), skipped, nil
+}
+
+// readFileSDConfig reads the target groups of every file matching the patterns of the file service discovery config.
+// Relative patterns are resolved against the given directory.
+func readFileSDConfig(c *file.SDConfig, dir string) ([]*targetgroup.Group, error) {
+	var groups []*targetgroup.Group
+	for _, pattern := range c.Files {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			g, err := readTargetGroups(f)
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, g...)
+		}
+	}
+	return groups, nil
+}
+
+// readTargetGroups reads a file in the format of the file service discovery, either JSON or YAML.
+func readTargetGroups(path string) ([]*targetgroup.Group, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var groups []*targetgroup.Group
+	// JSON is valid YAML, so both formats are read the same way
+	if err := yaml.UnmarshalStrict(content, &groups); err != nil {
+		return nil, fmt.Errorf("error unmarshaling targets of %s: %w", path, err)
+	}
+	return groups, nil
+}
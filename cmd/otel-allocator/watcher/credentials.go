@@ -0,0 +1,202 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	allocatorconfig "github.com/otel-allocator/config"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus-operator/prometheus-operator/pkg/assets"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// tlsAssetsDir is the directory the config generator expects the TLS assets in, the files are never written
+// by the TargetAllocator, their contents are handed to the collectors instead.
+const tlsAssetsDir = "/etc/prometheus/certs"
+
+// addServiceMonitorAssets fetches the Secrets and ConfigMaps referenced by the ServiceMonitor's endpoints into the
+// store, with the keys the config generator looks them up with.
+func addServiceMonitorAssets(ctx context.Context, store *assets.Store, sm *monitoringv1.ServiceMonitor) error {
+	for i, endpoint := range sm.Spec.Endpoints {
+		key := fmt.Sprintf("serviceMonitor/%s/%s/%d", sm.Namespace, sm.Name, i)
+		if err := store.AddBearerToken(ctx, sm.Namespace, endpoint.BearerTokenSecret, key); err != nil {
+			return err
+		}
+		if err := store.AddBasicAuth(ctx, sm.Namespace, endpoint.BasicAuth, key); err != nil {
+			return err
+		}
+		if err := store.AddTLSConfig(ctx, sm.Namespace, endpoint.TLSConfig); err != nil {
+			return err
+		}
+		if err := store.AddOAuth2(ctx, sm.Namespace, endpoint.OAuth2, key); err != nil {
+			return err
+		}
+		authKey := fmt.Sprintf("serviceMonitor/auth/%s/%s/%d", sm.Namespace, sm.Name, i)
+		if err := store.AddSafeAuthorizationCredentials(ctx, sm.Namespace, endpoint.Authorization, authKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPodMonitorAssets fetches the Secrets and ConfigMaps referenced by the PodMonitor's endpoints into the store.
+func addPodMonitorAssets(ctx context.Context, store *assets.Store, pm *monitoringv1.PodMonitor) error {
+	for i, endpoint := range pm.Spec.PodMetricsEndpoints {
+		key := fmt.Sprintf("podMonitor/%s/%s/%d", pm.Namespace, pm.Name, i)
+		if err := store.AddBearerToken(ctx, pm.Namespace, endpoint.BearerTokenSecret, key); err != nil {
+			return err
+		}
+		if err := store.AddBasicAuth(ctx, pm.Namespace, endpoint.BasicAuth, key); err != nil {
+			return err
+		}
+		if endpoint.TLSConfig != nil {
+			if err := store.AddSafeTLSConfig(ctx, pm.Namespace, &endpoint.TLSConfig.SafeTLSConfig); err != nil {
+				return err
+			}
+		}
+		if err := store.AddOAuth2(ctx, pm.Namespace, endpoint.OAuth2, key); err != nil {
+			return err
+		}
+		authKey := fmt.Sprintf("podMonitor/auth/%s/%s/%d", pm.Namespace, pm.Name, i)
+		if err := store.AddSafeAuthorizationCredentials(ctx, pm.Namespace, endpoint.Authorization, authKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addProbeAssets fetches the Secrets and ConfigMaps referenced by the Probe into the store.
+func addProbeAssets(ctx context.Context, store *assets.Store, probe *monitoringv1.Probe) error {
+	key := fmt.Sprintf("probe/%s/%s", probe.Namespace, probe.Name)
+	if err := store.AddBearerToken(ctx, probe.Namespace, probe.Spec.BearerTokenSecret, key); err != nil {
+		return err
+	}
+	if err := store.AddBasicAuth(ctx, probe.Namespace, probe.Spec.BasicAuth, key); err != nil {
+		return err
+	}
+	if probe.Spec.TLSConfig != nil {
+		if err := store.AddSafeTLSConfig(ctx, probe.Namespace, &probe.Spec.TLSConfig.SafeTLSConfig); err != nil {
+			return err
+		}
+	}
+	authKey := fmt.Sprintf("probe/auth/%s/%s", probe.Namespace, probe.Name)
+	if err := store.AddSafeAuthorizationCredentials(ctx, probe.Namespace, probe.Spec.Authorization, authKey); err != nil {
+		return err
+	}
+	return store.AddOAuth2(ctx, probe.Namespace, probe.Spec.OAuth2, key)
+}
+
+// tlsAssetFiles returns the contents of the TLS assets in the store, indexed by the path the generated
+// scrape configs refer to them with.
+func tlsAssetFiles(store *assets.Store) map[string]string {
+	files := make(map[string]string, len(store.TLSAssets))
+	for key, asset := range store.TLSAssets {
+		files[path.Join(tlsAssetsDir, key.String())] = string(asset)
+	}
+	return files
+}
+
+// referenceKey identifies a Secret or ConfigMap, with its resource, namespace and name.
+func referenceKey(resource, namespace, name string) string {
+	return resource + "/" + namespace + "/" + name
+}
+
+// recordingSecrets records the key of every Secret fetched, whether it exists or not.
+type recordingSecrets struct {
+	typedcorev1.SecretsGetter
+	fetched map[string]bool
+}
+
+func (r recordingSecrets) Secrets(namespace string) typedcorev1.SecretInterface {
+	return recordingSecretInterface{SecretInterface: r.SecretsGetter.Secrets(namespace), namespace: namespace, fetched: r.fetched}
+}
+
+type recordingSecretInterface struct {
+	typedcorev1.SecretInterface
+	namespace string
+	fetched   map[string]bool
+}
+
+func (r recordingSecretInterface) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Secret, error) {
+	r.fetched[referenceKey("secrets", r.namespace, name)] = true
+	return r.SecretInterface.Get(ctx, name, opts)
+}
+
+// recordingConfigMaps records the key of every ConfigMap fetched, whether it exists or not.
+type recordingConfigMaps struct {
+	typedcorev1.ConfigMapsGetter
+	fetched map[string]bool
+}
+
+func (r recordingConfigMaps) ConfigMaps(namespace string) typedcorev1.ConfigMapInterface {
+	return recordingConfigMapInterface{ConfigMapInterface: r.ConfigMapsGetter.ConfigMaps(namespace), namespace: namespace, fetched: r.fetched}
+}
+
+type recordingConfigMapInterface struct {
+	typedcorev1.ConfigMapInterface
+	namespace string
+	fetched   map[string]bool
+}
+
+func (r recordingConfigMapInterface) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ConfigMap, error) {
+	r.fetched[referenceKey("configmaps", r.namespace, name)] = true
+	return r.ConfigMapInterface.Get(ctx, name, opts)
+}
+
+// setReferenced replaces the Secrets and ConfigMaps the last generated config was built from.
+func (w *PrometheusCRWatcher) setReferenced(fetched map[string]bool) {
+	w.referencedMu.Lock()
+	defer w.referencedMu.Unlock()
+	w.referenced = fetched
+}
+
+// notifyReferenceChange generates the config again when the given Secret or ConfigMap was used to build it,
+// so that rotated credentials are applied right away.
+func (w *PrometheusCRWatcher) notifyReferenceChange(resource string, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+
+	w.referencedMu.Lock()
+	referenced := w.referenced[referenceKey(resource, namespace, name)]
+	w.referencedMu.Unlock()
+	if referenced {
+		w.notifyChange()
+	}
+}
+
+// startReferenceInformers watches the metadata of the Secrets and ConfigMaps, which is enough to know when their
+// content changes without keeping them in memory. The initial sync isn't awaited, as the informers only trigger
+// the generation of the config, which fetches the referenced objects itself.
+func (w *PrometheusCRWatcher) startReferenceInformers() {
+	factory := metadatainformer.NewSharedInformerFactory(w.metadataClient, allocatorconfig.DefaultResyncTime)
+	for _, resource := range []string{"secrets", "configmaps"} {
+		resource := resource
+		factory.ForResource(v1.SchemeGroupVersion.WithResource(resource)).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				w.notifyReferenceChange(resource, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				// the resync sends updates of unchanged objects
+				if oldObj.(metav1.Object).GetResourceVersion() != newObj.(metav1.Object).GetResourceVersion() {
+					w.notifyReferenceChange(resource, newObj)
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				w.notifyReferenceChange(resource, obj)
+			},
+		})
+	}
+	factory.Start(w.stopChannel)
+}
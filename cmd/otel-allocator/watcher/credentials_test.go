@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path"
+	"testing"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// caCertificate returns a self-signed CA certificate in the PEM format.
+func caCertificate(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func secretKey(name, key string) v1.SecretKeySelector {
+	return v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: name}, Key: key}
+}
+
+func TestMonitorCredentials(t *testing.T) {
+	ca := caCertificate(t)
+	withCredentials := serviceMonitor("default", "with-credentials", nil)
+	withCredentials.Spec.Endpoints[0].BasicAuth = &monitoringv1.BasicAuth{
+		Username: secretKey("credentials", "username"),
+		Password: secretKey("credentials", "password"),
+	}
+	caKey := secretKey("credentials", "ca.crt")
+	withCredentials.Spec.Endpoints[0].TLSConfig = &monitoringv1.TLSConfig{
+		SafeTLSConfig: monitoringv1.SafeTLSConfig{CA: monitoringv1.SecretOrConfigMap{Secret: &caKey}},
+	}
+	withToken := podMonitor("default", "with-token", nil)
+	withToken.Spec.PodMetricsEndpoints[0].BearerTokenSecret = secretKey("credentials", "token")
+	missingSecret := serviceMonitor("default", "missing-secret", nil)
+	missingSecret.Spec.Endpoints[0].BearerTokenSecret = secretKey("missing", "token")
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("secret-password"),
+			"ca.crt":   []byte(ca),
+			"token":    []byte("secret-token"),
+		},
+	}
+	clientset := withMonitoringResources(fake.NewSimpleClientset(secret), monitoringv1.ServiceMonitorName, monitoringv1.PodMonitorName)
+	recorder := record.NewFakeRecorder(10)
+
+	w, promCfg := createPromConfig(t, selectAll, []runtime.Object{withCredentials, withToken, missingSecret}, clientset, recorder)
+
+	jobs := map[string]int{}
+	for i, scrapeConfig := range promCfg.ScrapeConfigs {
+		jobs[scrapeConfig.JobName] = i
+	}
+	require.Len(t, jobs, 2)
+
+	// the credentials are resolved from the secret
+	httpConfig := promCfg.ScrapeConfigs[jobs["serviceMonitor/default/with-credentials/0"]].HTTPClientConfig
+	require.NotNil(t, httpConfig.BasicAuth)
+	assert.Equal(t, "user", httpConfig.BasicAuth.Username)
+	assert.Equal(t, "secret-password", string(httpConfig.BasicAuth.Password))
+	caFile := httpConfig.TLSConfig.CAFile
+	assert.Equal(t, tlsAssetsDir, path.Dir(caFile))
+	assert.Equal(t, map[string]string{caFile: ca}, w.TLSAssets())
+
+	tokenConfig := promCfg.ScrapeConfigs[jobs["podMonitor/default/with-token/0"]].HTTPClientConfig
+	var token string
+	if tokenConfig.Authorization != nil {
+		token = string(tokenConfig.Authorization.Credentials)
+	} else {
+		token = string(tokenConfig.BearerToken)
+	}
+	assert.Equal(t, "secret-token", token)
+
+	// the monitor whose secret doesn't exist is skipped and reported
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Warning CredentialsUnavailable")
+	assert.Contains(t, event, "missing")
+}
+
+func TestReferenceChanges(t *testing.T) {
+	withToken := serviceMonitor("default", "with-token", nil)
+	withToken.Spec.Endpoints[0].BearerTokenSecret = secretKey("credentials", "token")
+	missingSecret := serviceMonitor("default", "missing-secret", nil)
+	missingSecret.Spec.Endpoints[0].BearerTokenSecret = secretKey("missing", "token")
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+		Data:       map[string][]byte{"token": []byte("secret-token")},
+	}
+	clientset := withMonitoringResources(fake.NewSimpleClientset(secret), monitoringv1.ServiceMonitorName)
+	w, _ := createPromConfig(t, selectAll, []runtime.Object{withToken, missingSecret}, clientset, record.NewFakeRecorder(10))
+
+	for _, tt := range []struct {
+		desc     string
+		resource string
+		obj      interface{}
+		expected bool
+	}{
+		{desc: "referenced secret", resource: "secrets", obj: objectMetadata("default", "credentials"), expected: true},
+		{desc: "missing secret", resource: "secrets", obj: objectMetadata("default", "missing"), expected: true},
+		{desc: "deleted secret", resource: "secrets", obj: cache.DeletedFinalStateUnknown{Key: "default/credentials"}, expected: true},
+		{desc: "other secret", resource: "secrets", obj: objectMetadata("default", "other"), expected: false},
+		{desc: "secret of another namespace", resource: "secrets", obj: objectMetadata("other", "credentials"), expected: false},
+		{desc: "config map with the same name", resource: "configmaps", obj: objectMetadata("default", "credentials"), expected: false},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			// drop the changes notified so far
+			select {
+			case <-w.changes:
+			default:
+			}
+			w.notifyReferenceChange(tt.resource, tt.obj)
+			assert.Equal(t, tt.expected, len(w.changes) == 1)
+		})
+	}
+}
+
+func objectMetadata(namespace, name string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
@@ -1,6 +1,8 @@
 package watcher
 
 import (
+	"bytes"
+	"io/ioutil"
 	"path/filepath"
 
 	"github.com/fsnotify/fsnotify"
@@ -8,9 +10,16 @@ import (
 	"github.com/otel-allocator/config"
 )
 
+// FileWatcher reports the changes of the content of the config file. The directory of the file is watched rather
+// than the file itself, as a mounted ConfigMap is updated by swapping the symlink the file points to, which produces
+// create, rename and remove events for other files of the directory. Files edited in place produce write events
+// for the file itself instead. Whatever the events, the file is read again and only a different content is reported.
 type FileWatcher struct {
+	logger         logr.Logger
 	configFilePath string
 	watcher        *fsnotify.Watcher
+	// content is the content of the config file when it was last reported or when the watcher started
+	content []byte
 }
 
 func newConfigMapWatcher(logger logr.Logger, config config.CLIConfig) (FileWatcher, error) {
@@ -21,6 +30,7 @@ func newConfigMapWatcher(logger logr.Logger, config config.CLIConfig) (FileWatch
 	}
 
 	return FileWatcher{
+		logger:         logger,
 		configFilePath: *config.ConfigFilePath,
 		watcher:        fileWatcher,
 	}, nil
@@ -31,18 +41,30 @@ func (f *FileWatcher) Start(upstreamEvents chan Event, upstreamErrors chan error
 	if err != nil {
 		return err
 	}
+	// the config file was loaded before the watcher started, so only later changes have to be reported
+	f.content, _ = ioutil.ReadFile(f.configFilePath)
 
 	// translate and copy to central event channel
 	go func() {
 		for {
 			select {
-			case fileEvent := <-f.watcher.Events:
-				if fileEvent.Op == fsnotify.Create {
+			case fileEvent, ok := <-f.watcher.Events:
+				if !ok {
+					return
+				}
+				// permission changes don't change the content
+				if fileEvent.Op == fsnotify.Chmod {
+					continue
+				}
+				if f.changed() {
 					upstreamEvents <- Event{
 						Source: EventSourceConfigMap,
 					}
 				}
-			case err := <-f.watcher.Errors:
+			case err, ok := <-f.watcher.Errors:
+				if !ok {
+					return
+				}
 				upstreamErrors <- err
 			}
 		}
@@ -50,6 +72,22 @@ func (f *FileWatcher) Start(upstreamEvents chan Event, upstreamErrors chan error
 	return nil
 }
 
+// changed reads the config file again and returns whether its content changed since the last time.
+// A file which can't be read is ignored, as it's missing in the middle of a symlink swap, the event
+// completing the swap reports the new content.
+func (f *FileWatcher) changed() bool {
+	content, err := ioutil.ReadFile(f.configFilePath)
+	if err != nil {
+		f.logger.V(1).Info("Can't read the config file", "path", f.configFilePath, "error", err.Error())
+		return false
+	}
+	if bytes.Equal(content, f.content) {
+		return false
+	}
+	f.content = content
+	return true
+}
+
 func (f *FileWatcher) Close() error {
 	return f.watcher.Close()
 }
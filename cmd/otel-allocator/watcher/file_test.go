@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/otel-allocator/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startFileWatcher watches the config file at the given path and returns the channel receiving its events.
+func startFileWatcher(t *testing.T, path string) chan Event {
+	w, err := newConfigMapWatcher(logr.Discard(), config.CLIConfig{ConfigFilePath: &path})
+	require.NoError(t, err)
+	events := make(chan Event, 10)
+	require.NoError(t, w.Start(events, make(chan error, 10)))
+	t.Cleanup(func() { w.Close() })
+	return events
+}
+
+// receivedEvents returns the number of events received until none arrived for a while.
+func receivedEvents(events chan Event) int {
+	received := 0
+	for {
+		select {
+		case <-events:
+			received++
+		case <-time.After(200 * time.Millisecond):
+			return received
+		}
+	}
+}
+
+// writeConfigMapVersion writes the file into a new timestamped directory, like the kubelet does when
+// a ConfigMap volume is updated.
+func writeConfigMapVersion(t *testing.T, dir, version, content string) {
+	versionDir := filepath.Join(dir, version)
+	require.NoError(t, os.Mkdir(versionDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(versionDir, "targetallocator.yaml"), []byte(content), 0644))
+}
+
+func TestConfigMapSymlinkSwap(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigMapVersion(t, dir, "..v1", "label_selector: {app: a}")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "targetallocator.yaml"), filepath.Join(dir, "targetallocator.yaml")))
+
+	events := startFileWatcher(t, filepath.Join(dir, "targetallocator.yaml"))
+
+	// the new version is linked with a temporary symlink which replaces the current one, then the old version is removed
+	writeConfigMapVersion(t, dir, "..v2", "label_selector: {app: b}")
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "..v1")))
+	assert.Equal(t, 1, receivedEvents(events))
+
+	// a version with the same content isn't reported
+	writeConfigMapVersion(t, dir, "..v3", "label_selector: {app: b}")
+	require.NoError(t, os.Symlink("..v3", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	assert.Equal(t, 0, receivedEvents(events))
+}
+
+func TestConfigFileWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targetallocator.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("label_selector: {app: a}"), 0644))
+
+	events := startFileWatcher(t, path)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("label_selector: {app: b}"), 0644))
+	assert.Equal(t, 1, receivedEvents(events))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("label_selector: {app: b}"), 0644))
+	assert.Equal(t, 0, receivedEvents(events))
+}
@@ -21,6 +21,12 @@ type Watcher interface {
 	Close() error
 }
 
+// configurable is implemented by the watchers which depend on the config file.
+type configurable interface {
+	// ApplyConfig applies a config file which was reloaded
+	ApplyConfig(cfg config.Config) error
+}
+
 type Event struct {
 	Source  EventSource
 	Watcher *Watcher
@@ -33,6 +39,17 @@ const (
 	EventSourcePrometheusCR
 )
 
+var (
+	eventSourceToString = map[EventSource]string{
+		EventSourceConfigMap:    "EventSourceConfigMap",
+		EventSourcePrometheusCR: "EventSourcePrometheusCR",
+	}
+)
+
+func (e EventSource) String() string {
+	return eventSourceToString[e]
+}
+
 func NewWatcher(logger logr.Logger, config config.CLIConfig, allocator *allocation.Allocator) (*Manager, error) {
 	watcher := Manager{
 		allocator: allocator,
@@ -76,6 +93,18 @@ func (manager *Manager) Close() error {
 	return nil
 }
 
+// ApplyConfig applies a reloaded config file to the watchers depending on it.
+func (manager *Manager) ApplyConfig(cfg config.Config) error {
+	for _, watcher := range manager.watchers {
+		if w, ok := watcher.(configurable); ok {
+			if err := w.ApplyConfig(cfg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (manager *Manager) Start() error {
 	var errors []error
 	for _, watcher := range manager.watchers {
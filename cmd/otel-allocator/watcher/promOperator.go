@@ -1,7 +1,14 @@
 package watcher
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 
 	allocatorconfig "github.com/otel-allocator/config"
 
@@ -16,31 +23,79 @@ import (
 	kubeDiscovery "github.com/prometheus/prometheus/discovery/kubernetes"
 	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 func newCRDMonitorWatcher(logger logr.Logger, config allocatorconfig.CLIConfig) (*PrometheusCRWatcher, error) {
+	cfg, err := allocatorconfig.Load(*config.ConfigFilePath)
+	if err != nil {
+		return nil, err
+	}
+
 	mClient, err := monitoringclient.NewForConfig(config.ClusterConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	factory := informers.NewMonitoringInformerFactories(map[string]struct{}{v1.NamespaceAll: {}}, map[string]struct{}{}, mClient, allocatorconfig.DefaultResyncTime, nil) //TODO decide what strategy to use regarding namespaces
+	clientset, err := kubernetes.NewForConfig(config.ClusterConfig)
+	if err != nil {
+		return nil, err
+	}
 
-	serviceMonitorInformers, err := informers.NewInformersForResource(factory, monitoringv1.SchemeGroupVersion.WithResource(monitoringv1.ServiceMonitorName))
+	metadataClient, err := metadata.NewForConfig(config.ClusterConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	podMonitorInformers, err := informers.NewInformersForResource(factory, monitoringv1.SchemeGroupVersion.WithResource(monitoringv1.PodMonitorName))
+	// the events are recorded on the monitors, so the scheme has to know about them
+	scheme := runtime.NewScheme()
+	if err := monitoringv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme, v1.EventSource{Component: "opentelemetry-targetallocator"})
+
+	w, err := newPrometheusCRWatcher(logger, mClient, clientset, recorder, cfg)
 	if err != nil {
+		broadcaster.Shutdown()
 		return nil, err
 	}
+	w.eventBroadcaster = broadcaster
+	w.metadataClient = metadataClient
+	w.debounceInterval = *config.PromCRWatcherConf.DebounceInterval
+	return w, nil
+}
 
-	monitoringInformers := map[string]*informers.ForResource{
-		monitoringv1.ServiceMonitorName: serviceMonitorInformers,
-		monitoringv1.PodMonitorName:     podMonitorInformers,
+func newPrometheusCRWatcher(logger logr.Logger, mClient monitoringclient.Interface, clientset kubernetes.Interface, recorder record.EventRecorder, cfg allocatorconfig.Config) (*PrometheusCRWatcher, error) {
+	// the monitors are watched in all namespaces, and filtered by the namespace selector when the config is created,
+	// so that namespaces being labeled or unlabeled are taken into account without recreating the informers
+	factory := informers.NewMonitoringInformerFactories(map[string]struct{}{v1.NamespaceAll: {}}, map[string]struct{}{}, mClient, allocatorconfig.DefaultResyncTime, nil)
+
+	// only watch the resources whose CRDs are installed, the informers would never sync otherwise
+	available, err := availableMonitoringResources(clientset.Discovery())
+	if err != nil {
+		return nil, err
+	}
+	monitoringInformers := make(map[string]*informers.ForResource)
+	for _, resource := range []string{monitoringv1.ServiceMonitorName, monitoringv1.PodMonitorName, monitoringv1.ProbeName} {
+		if !available[resource] {
+			continue
+		}
+		monitoringInformers[resource], err = informers.NewInformersForResource(factory, monitoringv1.SchemeGroupVersion.WithResource(resource))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	generator, err := prometheus.NewConfigGenerator(log.NewNopLogger(), &monitoringv1.Prometheus{}) // TODO replace Nop?
@@ -48,19 +103,128 @@ func newCRDMonitorWatcher(logger logr.Logger, config allocatorconfig.CLIConfig)
 		return nil, err
 	}
 
-	return &PrometheusCRWatcher{
+	w := &PrometheusCRWatcher{
+		log:                  logger,
 		kubeMonitoringClient: mClient,
+		kubeClient:           clientset,
+		recorder:             recorder,
 		informers:            monitoringInformers,
 		stopChannel:          make(chan struct{}),
+		changes:              make(chan struct{}, 1),
+		debounceInterval:     allocatorconfig.DefaultPrometheusCRDebounceInterval,
+		namespaceSyncTimeout: allocatorconfig.DefaultNamespaceSyncTimeout,
 		configGenerator:      generator,
-	}, nil
+	}
+	if err := w.setSelectors(cfg); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// setSelectors replaces the selectors of the monitors with the ones of the config. The namespaces are only watched
+// once they have to be selected, which requires permission to list them.
+func (w *PrometheusCRWatcher) setSelectors(cfg allocatorconfig.Config) error {
+	serviceMonitorSelector, err := cfg.ServiceMonitorSelector.Selector()
+	if err != nil {
+		return fmt.Errorf("invalid service monitor selector: %w", err)
+	}
+	podMonitorSelector, err := cfg.PodMonitorSelector.Selector()
+	if err != nil {
+		return fmt.Errorf("invalid pod monitor selector: %w", err)
+	}
+	probeSelector, err := cfg.ProbeSelector.Selector()
+	if err != nil {
+		return fmt.Errorf("invalid probe selector: %w", err)
+	}
+	// unlike the monitors, all the namespaces are selected when the namespace selector isn't set
+	namespaceSelector := labels.Everything()
+	if cfg.NamespaceSelector != nil {
+		namespaceSelector, err = cfg.NamespaceSelector.Selector()
+		if err != nil {
+			return fmt.Errorf("invalid namespace selector: %w", err)
+		}
+	}
+
+	w.serviceMonitorSelector = serviceMonitorSelector
+	w.podMonitorSelector = podMonitorSelector
+	w.probeSelector = probeSelector
+	w.namespaceSelector = namespaceSelector
+	if w.namespaceInformer == nil && !namespaceSelector.Empty() {
+		w.namespaceInformer = kubeinformers.NewSharedInformerFactory(w.kubeClient, allocatorconfig.DefaultResyncTime).Core().V1().Namespaces().Informer()
+	}
+	return nil
+}
+
+// ApplyConfig applies the selectors of a reloaded config file. The config is generated again if they changed.
+func (w *PrometheusCRWatcher) ApplyConfig(cfg allocatorconfig.Config) error {
+	previous := []labels.Selector{w.serviceMonitorSelector, w.podMonitorSelector, w.probeSelector, w.namespaceSelector}
+	watchingNamespaces := w.namespaceInformer != nil
+	if err := w.setSelectors(cfg); err != nil {
+		return err
+	}
+	if !watchingNamespaces && w.namespaceInformer != nil {
+		w.startNamespaceInformer()
+	}
+
+	current := []labels.Selector{w.serviceMonitorSelector, w.podMonitorSelector, w.probeSelector, w.namespaceSelector}
+	for i := range current {
+		// the selectors matching nothing and everything are both written as an empty string
+		if current[i].String() != previous[i].String() || current[i].Empty() != previous[i].Empty() {
+			w.notifyChange()
+			break
+		}
+	}
+	return nil
+}
+
+// availableMonitoringResources returns the monitoring.coreos.com/v1 resources served by the API server.
+func availableMonitoringResources(client discovery.DiscoveryInterface) (map[string]bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(monitoringv1.SchemeGroupVersion.String())
+	if apierrors.IsNotFound(err) {
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the Prometheus Operator resources: %w", err)
+	}
+	available := make(map[string]bool, len(resources.APIResources))
+	for _, resource := range resources.APIResources {
+		available[resource.Name] = true
+	}
+	return available, nil
 }
 
 type PrometheusCRWatcher struct {
-	kubeMonitoringClient *monitoringclient.Clientset
-	informers            map[string]*informers.ForResource
-	stopChannel          chan struct{}
+	log                  logr.Logger
+	kubeMonitoringClient monitoringclient.Interface
+	// kubeClient fetches the Secrets and ConfigMaps referenced by the monitors
+	kubeClient kubernetes.Interface
+	// metadataClient watches the Secrets and ConfigMaps referenced by the monitors, nil if they aren't watched
+	metadataClient   metadata.Interface
+	recorder         record.EventRecorder
+	eventBroadcaster record.EventBroadcaster
+	informers        map[string]*informers.ForResource
+	// namespaceInformer is nil until a namespace selector is set, and keeps running if it is unset again
+	namespaceInformer cache.SharedIndexInformer
+	stopChannel       chan struct{}
+	// changes holds at most one pending change, the changes arriving while one is pending are coalesced into it
+	changes          chan struct{}
+	debounceInterval time.Duration
+	// namespaceSyncTimeout bounds the wait for the initial sync of the namespaces
+	namespaceSyncTimeout time.Duration
 	configGenerator      *prometheus.ConfigGenerator
+
+	serviceMonitorSelector labels.Selector
+	podMonitorSelector     labels.Selector
+	probeSelector          labels.Selector
+	namespaceSelector      labels.Selector
+
+	// tlsAssets holds the contents of the files referenced by the TLS configs of the last generated config
+	tlsAssets map[string]string
+	// configHash identifies the content of the last generated config, including its credentials and TLS assets
+	configHash string
+	// referenced holds the keys of the Secrets and ConfigMaps the last generated config was built from
+	referenced   map[string]bool
+	referencedMu sync.Mutex
 }
 
 // Start wrapped informers and wait for an initial sync
@@ -72,6 +236,10 @@ func (w *PrometheusCRWatcher) Start(upstreamEvents chan Event, upstreamErrors ch
 	}
 	success := true
 
+	// the informers only record that something changed, a single event is sent upstream once the
+	// changes settled, so that syncing or upgrading many monitors at once doesn't regenerate the config for each of them
+	go w.debounce(upstreamEvents, event)
+
 	for name, resource := range w.informers {
 		resource.Start(w.stopChannel)
 
@@ -81,16 +249,22 @@ func (w *PrometheusCRWatcher) Start(upstreamEvents chan Event, upstreamErrors ch
 
 		resource.AddEventHandler(cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				upstreamEvents <- event
+				w.notifyChange()
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				upstreamEvents <- event
+				w.notifyChange()
 			},
 			DeleteFunc: func(obj interface{}) {
-				upstreamEvents <- event
+				w.notifyChange()
 			},
 		})
 	}
+	if w.namespaceInformer != nil {
+		w.startNamespaceInformer()
+	}
+	if w.metadataClient != nil {
+		w.startReferenceInformers()
+	}
 	if !success {
 		return fmt.Errorf("failed to sync cache")
 	}
@@ -98,43 +272,198 @@ func (w *PrometheusCRWatcher) Start(upstreamEvents chan Event, upstreamErrors ch
 	return nil
 }
 
+// startNamespaceInformer starts watching the namespaces and waits for the initial sync, for at most
+// namespaceSyncTimeout. When the namespaces can't be listed in time, no namespace is selected until they are,
+// and the config is generated again then.
+func (w *PrometheusCRWatcher) startNamespaceInformer() {
+	// only changes of the labels can change which namespaces are selected
+	w.namespaceInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.notifyChange()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if !reflect.DeepEqual(oldObj.(*v1.Namespace).Labels, newObj.(*v1.Namespace).Labels) {
+				w.notifyChange()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			w.notifyChange()
+		},
+	})
+	go w.namespaceInformer.Run(w.stopChannel)
+
+	timeout := make(chan struct{})
+	time.AfterFunc(w.namespaceSyncTimeout, func() { close(timeout) })
+	stop := make(chan struct{})
+	go func() {
+		defer close(stop)
+		select {
+		case <-w.stopChannel:
+		case <-timeout:
+		}
+	}()
+	if !cache.WaitForNamedCacheSync("namespaces", stop, w.namespaceInformer.HasSynced) {
+		w.log.Error(fmt.Errorf("namespaces not synced within %s", w.namespaceSyncTimeout), "Can't list the namespaces, no namespace is selected until they are listed, check that the ServiceAccount can list and watch them")
+	}
+}
+
+// notifyChange records that the config has to be generated again, without blocking the informer.
+func (w *PrometheusCRWatcher) notifyChange() {
+	select {
+	case w.changes <- struct{}{}:
+	default:
+		// a change is already pending, the config generated for it will include this one too
+	}
+}
+
+// debounce sends the event upstream once for all the changes notified within the debounce interval following
+// the first one. The delay is bounded by the interval, a steady stream of changes doesn't postpone the event.
+func (w *PrometheusCRWatcher) debounce(upstreamEvents chan Event, event Event) {
+	for {
+		select {
+		case <-w.stopChannel:
+			return
+		case <-w.changes:
+		}
+
+		timer := time.NewTimer(w.debounceInterval)
+		select {
+		case <-w.stopChannel:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// the changes notified while waiting are part of the config generated for this event
+		select {
+		case <-w.changes:
+		default:
+		}
+
+		select {
+		case <-w.stopChannel:
+			return
+		case upstreamEvents <- event:
+		}
+	}
+}
+
 func (w *PrometheusCRWatcher) Close() error {
-	w.stopChannel <- struct{}{}
+	// closing stops all informers, a single send would only stop one of them
+	close(w.stopChannel)
+	if w.eventBroadcaster != nil {
+		w.eventBroadcaster.Shutdown()
+	}
 	return nil
 }
 
+// matchesNamespace returns whether the monitors of the given namespace are selected by the namespace selector.
+func (w *PrometheusCRWatcher) matchesNamespace(name string) bool {
+	if w.namespaceInformer == nil || w.namespaceSelector.Empty() {
+		return true
+	}
+	obj, exists, err := w.namespaceInformer.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return false
+	}
+	return w.namespaceSelector.Matches(labels.Set(obj.(*v1.Namespace).Labels))
+}
+
+// listMonitors calls appendFn with every object of the given resource matching the selectors.
+// Resources which aren't installed in the cluster have no objects.
+func (w *PrometheusCRWatcher) listMonitors(resource string, selector labels.Selector, appendFn cache.AppendFunc) error {
+	informer, ok := w.informers[resource]
+	if !ok {
+		return nil
+	}
+	return informer.ListAll(selector, func(obj interface{}) {
+		if w.matchesNamespace(obj.(metav1.Object).GetNamespace()) {
+			appendFn(obj)
+		}
+	})
+}
+
+// reject reports a monitor which is left out of the generated config.
+func (w *PrometheusCRWatcher) reject(obj runtime.Object, err error) {
+	key, _ := cache.MetaNamespaceKeyFunc(obj)
+	w.log.Error(err, "Skipping monitor, its credentials can't be fetched", "monitor", key)
+	w.recorder.Eventf(obj, v1.EventTypeWarning, "CredentialsUnavailable", "Skipped by the TargetAllocator: %v", err)
+}
+
+// ConfigHash returns a hash of the content of the last generated config, its credentials and TLS assets included.
+// The hashes of two configs are the same if and only if applying either of them has the same effect.
+func (w *PrometheusCRWatcher) ConfigHash() string {
+	return w.configHash
+}
+
+// TLSAssets returns the contents of the CA certificates, certificates and keys referenced by the last generated
+// config, indexed by their path in the config.
+func (w *PrometheusCRWatcher) TLSAssets() map[string]string {
+	return w.tlsAssets
+}
+
 func (w *PrometheusCRWatcher) CreatePromConfig(kubeConfigPath string) (*promconfig.Config, error) {
 	serviceMonitorInstances := make(map[string]*monitoringv1.ServiceMonitor)
-	smRetrieveErr := w.informers[monitoringv1.ServiceMonitorName].ListAll(labels.NewSelector(), func(sm interface{}) {
-		monitor := sm.(*monitoringv1.ServiceMonitor)
+	err := w.listMonitors(monitoringv1.ServiceMonitorName, w.serviceMonitorSelector, func(obj interface{}) {
+		monitor := obj.(*monitoringv1.ServiceMonitor)
 		key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(monitor)
 		serviceMonitorInstances[key] = monitor
 	})
-	if smRetrieveErr != nil {
-		return nil, smRetrieveErr
+	if err != nil {
+		return nil, err
 	}
 
 	podMonitorInstances := make(map[string]*monitoringv1.PodMonitor)
-	pmRetrieveErr := w.informers[monitoringv1.PodMonitorName].ListAll(labels.NewSelector(), func(pm interface{}) {
-		monitor := pm.(*monitoringv1.PodMonitor)
+	err = w.listMonitors(monitoringv1.PodMonitorName, w.podMonitorSelector, func(obj interface{}) {
+		monitor := obj.(*monitoringv1.PodMonitor)
 		key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(monitor)
 		podMonitorInstances[key] = monitor
 	})
-	if pmRetrieveErr != nil {
-		return nil, pmRetrieveErr
+	if err != nil {
+		return nil, err
 	}
 
-	store := assets.Store{
-		TLSAssets:       nil,
-		TokenAssets:     nil,
-		BasicAuthAssets: nil,
-		OAuth2Assets:    nil,
-		SigV4Assets:     nil,
+	probeInstances := make(map[string]*monitoringv1.Probe)
+	err = w.listMonitors(monitoringv1.ProbeName, w.probeSelector, func(obj interface{}) {
+		probe := obj.(*monitoringv1.Probe)
+		key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(probe)
+		probeInstances[key] = probe
+	})
+	if err != nil {
+		return nil, err
 	}
-	generatedConfig, err := w.configGenerator.Generate(&monitoringv1.Prometheus{}, serviceMonitorInstances, podMonitorInstances, map[string]*monitoringv1.Probe{}, &store, nil, nil, nil, []string{})
+
+	// fetch the credentials the monitors refer to, a monitor whose credentials can't be fetched is skipped
+	// rather than generating a job which would fail to authenticate
+	ctx := context.Background()
+	fetched := make(map[string]bool)
+	store := assets.NewStore(recordingConfigMaps{w.kubeClient.CoreV1(), fetched}, recordingSecrets{w.kubeClient.CoreV1(), fetched})
+	for key, monitor := range serviceMonitorInstances {
+		if err := addServiceMonitorAssets(ctx, store, monitor); err != nil {
+			w.reject(monitor, err)
+			delete(serviceMonitorInstances, key)
+		}
+	}
+	for key, monitor := range podMonitorInstances {
+		if err := addPodMonitorAssets(ctx, store, monitor); err != nil {
+			w.reject(monitor, err)
+			delete(podMonitorInstances, key)
+		}
+	}
+	for key, probe := range probeInstances {
+		if err := addProbeAssets(ctx, store, probe); err != nil {
+			w.reject(probe, err)
+			delete(probeInstances, key)
+		}
+	}
+
+	generatedConfig, err := w.configGenerator.Generate(&monitoringv1.Prometheus{}, serviceMonitorInstances, podMonitorInstances, probeInstances, store, nil, nil, nil, []string{})
 	if err != nil {
 		return nil, err
 	}
+	w.setReferenced(fetched)
+	w.tlsAssets = tlsAssetFiles(store)
+	w.configHash = configHash(generatedConfig, kubeConfigPath, w.tlsAssets)
 
 	promCfg := &promconfig.Config{}
 	unmarshalErr := yaml.Unmarshal(generatedConfig, promCfg)
@@ -154,3 +483,25 @@ func (w *PrometheusCRWatcher) CreatePromConfig(kubeConfigPath string) (*promconf
 	}
 	return promCfg, nil
 }
+
+// configHash hashes the generated config, which holds the credentials in clear, along with everything
+// added to it afterwards.
+func configHash(generatedConfig []byte, kubeConfigPath string, tlsAssets map[string]string) string {
+	h := sha256.New()
+	h.Write(generatedConfig)
+	h.Write([]byte{0})
+	h.Write([]byte(kubeConfigPath))
+
+	paths := make([]string, 0, len(tlsAssets))
+	for p := range tlsAssets {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+		h.Write([]byte(tlsAssets[p]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,398 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	allocatorconfig "github.com/otel-allocator/config"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	fakemonitoring "github.com/prometheus-operator/prometheus-operator/pkg/client/versioned/fake"
+	promconfig "github.com/prometheus/prometheus/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func serviceMonitor(namespace, name string, labels map[string]string) *monitoringv1.ServiceMonitor {
+	return &monitoringv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: monitoringv1.ServiceMonitorSpec{
+			Endpoints: []monitoringv1.Endpoint{{Port: "web"}},
+		},
+	}
+}
+
+func podMonitor(namespace, name string, labels map[string]string) *monitoringv1.PodMonitor {
+	return &monitoringv1.PodMonitor{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: monitoringv1.PodMonitorSpec{
+			PodMetricsEndpoints: []monitoringv1.PodMetricsEndpoint{{Port: "web"}},
+		},
+	}
+}
+
+func probe(namespace, name string, labels map[string]string) *monitoringv1.Probe {
+	return &monitoringv1.Probe{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: monitoringv1.ProbeSpec{
+			ProberSpec: monitoringv1.ProberSpec{URL: "blackbox-exporter:9115"},
+			Targets: monitoringv1.ProbeTargets{
+				StaticConfig: &monitoringv1.ProbeTargetStaticConfig{Targets: []string{"example.com"}},
+			},
+		},
+	}
+}
+
+func namespace(name string, labels map[string]string) *v1.Namespace {
+	return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+// withMonitoringResources makes the clientset report the given monitoring.coreos.com/v1 resources as installed.
+func withMonitoringResources(clientset *fake.Clientset, resources ...string) *fake.Clientset {
+	list := &metav1.APIResourceList{GroupVersion: monitoringv1.SchemeGroupVersion.String()}
+	for _, resource := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: resource})
+	}
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{list}
+	return clientset
+}
+
+// createPromConfig starts a watcher for the given objects and returns it along with the config it generated.
+func createPromConfig(t *testing.T, cfg allocatorconfig.Config, monitors []runtime.Object, clientset *fake.Clientset, recorder record.EventRecorder) (*PrometheusCRWatcher, *promconfig.Config) {
+	w, err := newPrometheusCRWatcher(logr.Discard(), fakemonitoring.NewSimpleClientset(monitors...), clientset, recorder, cfg)
+	require.NoError(t, err)
+
+	events := make(chan Event)
+	errors := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-events:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	require.NoError(t, w.Start(events, errors))
+	defer w.Close()
+
+	promCfg, err := w.CreatePromConfig("")
+	require.NoError(t, err)
+	return w, promCfg
+}
+
+// createJobNames starts a watcher for the given objects and returns the job names of the generated config.
+func createJobNames(t *testing.T, cfg allocatorconfig.Config, monitors []runtime.Object, clientset *fake.Clientset) []string {
+	_, promCfg := createPromConfig(t, cfg, monitors, clientset, record.NewFakeRecorder(10))
+
+	var jobs []string
+	for _, scrapeConfig := range promCfg.ScrapeConfigs {
+		jobs = append(jobs, scrapeConfig.JobName)
+	}
+	return jobs
+}
+
+// selectAll selects all the monitors, in all the namespaces.
+var selectAll = allocatorconfig.Config{
+	ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+	PodMonitorSelector:     &allocatorconfig.LabelSelector{},
+	ProbeSelector:          &allocatorconfig.LabelSelector{},
+}
+
+func TestMonitorSelectors(t *testing.T) {
+	monitors := []runtime.Object{
+		serviceMonitor("team-a", "sm-a", map[string]string{"team": "a"}),
+		serviceMonitor("team-b", "sm-b", map[string]string{"team": "b"}),
+		podMonitor("team-a", "pm-a", map[string]string{"team": "a"}),
+		podMonitor("team-b", "pm-b", map[string]string{"team": "b"}),
+		probe("team-a", "probe-a", map[string]string{"team": "a"}),
+		probe("team-b", "probe-b", map[string]string{"team": "b"}),
+	}
+	namespaces := []runtime.Object{
+		namespace("team-a", map[string]string{"tenant": "a"}),
+		namespace("team-b", map[string]string{"tenant": "b"}),
+	}
+
+	for _, tt := range []struct {
+		name     string
+		cfg      allocatorconfig.Config
+		expected []string
+	}{
+		{
+			name:     "no selectors",
+			cfg:      allocatorconfig.Config{},
+			expected: nil,
+		},
+		{
+			name: "empty selectors",
+			cfg:  selectAll,
+			expected: []string{
+				"serviceMonitor/team-a/sm-a/0",
+				"serviceMonitor/team-b/sm-b/0",
+				"podMonitor/team-a/pm-a/0",
+				"podMonitor/team-b/pm-b/0",
+				"probe/team-a/probe-a",
+				"probe/team-b/probe-b",
+			},
+		},
+		{
+			name: "service monitor selector",
+			cfg: allocatorconfig.Config{
+				ServiceMonitorSelector: &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				PodMonitorSelector:     &allocatorconfig.LabelSelector{},
+				ProbeSelector:          &allocatorconfig.LabelSelector{},
+			},
+			expected: []string{
+				"serviceMonitor/team-a/sm-a/0",
+				"podMonitor/team-a/pm-a/0",
+				"podMonitor/team-b/pm-b/0",
+				"probe/team-a/probe-a",
+				"probe/team-b/probe-b",
+			},
+		},
+		{
+			name: "pod monitor selector",
+			cfg: allocatorconfig.Config{
+				ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+				PodMonitorSelector: &allocatorconfig.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"a"}}},
+				},
+				ProbeSelector: &allocatorconfig.LabelSelector{},
+			},
+			expected: []string{
+				"serviceMonitor/team-a/sm-a/0",
+				"serviceMonitor/team-b/sm-b/0",
+				"podMonitor/team-b/pm-b/0",
+				"probe/team-a/probe-a",
+				"probe/team-b/probe-b",
+			},
+		},
+		{
+			name: "probe selector",
+			cfg: allocatorconfig.Config{
+				ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+				PodMonitorSelector:     &allocatorconfig.LabelSelector{},
+				ProbeSelector:          &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+			},
+			expected: []string{
+				"serviceMonitor/team-a/sm-a/0",
+				"serviceMonitor/team-b/sm-b/0",
+				"podMonitor/team-a/pm-a/0",
+				"podMonitor/team-b/pm-b/0",
+				"probe/team-b/probe-b",
+			},
+		},
+		{
+			name: "namespace selector",
+			cfg: allocatorconfig.Config{
+				ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+				PodMonitorSelector:     &allocatorconfig.LabelSelector{},
+				ProbeSelector:          &allocatorconfig.LabelSelector{},
+				NamespaceSelector:      &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}},
+			},
+			expected: []string{
+				"serviceMonitor/team-b/sm-b/0",
+				"podMonitor/team-b/pm-b/0",
+				"probe/team-b/probe-b",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := withMonitoringResources(fake.NewSimpleClientset(namespaces...), monitoringv1.ServiceMonitorName, monitoringv1.PodMonitorName, monitoringv1.ProbeName)
+			assert.ElementsMatch(t, tt.expected, createJobNames(t, tt.cfg, monitors, clientset))
+		})
+	}
+}
+
+func TestMissingMonitoringResources(t *testing.T) {
+	monitors := []runtime.Object{
+		serviceMonitor("default", "sm", nil),
+		probe("default", "probe", nil),
+	}
+
+	// the Probe CRD isn't installed
+	clientset := withMonitoringResources(fake.NewSimpleClientset(), monitoringv1.ServiceMonitorName, monitoringv1.PodMonitorName)
+	assert.ElementsMatch(t, []string{"serviceMonitor/default/sm/0"}, createJobNames(t, selectAll, monitors, clientset))
+
+	// none of the Prometheus Operator CRDs are installed
+	assert.Empty(t, createJobNames(t, selectAll, monitors, fake.NewSimpleClientset()))
+}
+
+func TestInvalidMonitorSelector(t *testing.T) {
+	cfg := allocatorconfig.Config{
+		NamespaceSelector: &allocatorconfig.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tenant", Operator: "Unknown"}},
+		},
+	}
+	_, err := newPrometheusCRWatcher(logr.Discard(), fakemonitoring.NewSimpleClientset(), fake.NewSimpleClientset(), record.NewFakeRecorder(10), cfg)
+	assert.Error(t, err)
+}
+
+func TestChangesAreCoalesced(t *testing.T) {
+	var monitors []runtime.Object
+	for i := 0; i < 50; i++ {
+		monitors = append(monitors, serviceMonitor("default", fmt.Sprintf("monitor-%d", i), nil))
+	}
+	mClient := fakemonitoring.NewSimpleClientset(monitors...)
+	clientset := withMonitoringResources(fake.NewSimpleClientset(), monitoringv1.ServiceMonitorName)
+	w, err := newPrometheusCRWatcher(logr.Discard(), mClient, clientset, record.NewFakeRecorder(10), selectAll)
+	require.NoError(t, err)
+	w.debounceInterval = 100 * time.Millisecond
+
+	events := make(chan Event)
+	require.NoError(t, w.Start(events, make(chan error)))
+	defer w.Close()
+
+	// expectEvents counts the events received until nothing happens for a few debounce intervals
+	expectEvents := func(expected int) {
+		received := 0
+		for {
+			select {
+			case event := <-events:
+				assert.Equal(t, EventSourcePrometheusCR, event.Source)
+				received++
+				continue
+			case <-time.After(5 * w.debounceInterval):
+			}
+			break
+		}
+		assert.Equal(t, expected, received)
+	}
+
+	// the initial list of all the monitors results in a single event
+	expectEvents(1)
+
+	for i := 50; i < 60; i++ {
+		_, err := mClient.MonitoringV1().ServiceMonitors("default").Create(context.Background(), serviceMonitor("default", fmt.Sprintf("monitor-%d", i), nil), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	expectEvents(1)
+}
+
+func TestConfigHash(t *testing.T) {
+	credentials := func(password string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "credentials"},
+			Data:       map[string][]byte{"username": []byte("user"), "password": []byte(password)},
+		}
+	}
+	withCredentials := serviceMonitor("default", "with-credentials", nil)
+	withCredentials.Spec.Endpoints[0].BasicAuth = &monitoringv1.BasicAuth{
+		Username: secretKey("credentials", "username"),
+		Password: secretKey("credentials", "password"),
+	}
+
+	hash := func(monitors []runtime.Object, secret *v1.Secret) string {
+		clientset := withMonitoringResources(fake.NewSimpleClientset(secret), monitoringv1.ServiceMonitorName)
+		w, _ := createPromConfig(t, selectAll, monitors, clientset, record.NewFakeRecorder(10))
+		return w.ConfigHash()
+	}
+
+	monitors := []runtime.Object{withCredentials, serviceMonitor("default", "other", nil)}
+	initial := hash(monitors, credentials("password"))
+	assert.NotEmpty(t, initial)
+	assert.Equal(t, initial, hash(monitors, credentials("password")))
+	assert.NotEqual(t, initial, hash(monitors[:1], credentials("password")))
+	// the credentials aren't part of the scrape configs' text, but still have to be applied when they change
+	assert.NotEqual(t, initial, hash(monitors, credentials("rotated")))
+}
+
+func TestApplyConfig(t *testing.T) {
+	monitors := []runtime.Object{
+		serviceMonitor("team-a", "sm-a", map[string]string{"team": "a"}),
+		serviceMonitor("team-b", "sm-b", map[string]string{"team": "b"}),
+	}
+	clientset := withMonitoringResources(fake.NewSimpleClientset(
+		namespace("team-a", map[string]string{"tenant": "a"}),
+		namespace("team-b", map[string]string{"tenant": "b"}),
+	), monitoringv1.ServiceMonitorName)
+	w, err := newPrometheusCRWatcher(logr.Discard(), fakemonitoring.NewSimpleClientset(monitors...), clientset, record.NewFakeRecorder(10), selectAll)
+	require.NoError(t, err)
+	w.debounceInterval = 10 * time.Millisecond
+
+	events := make(chan Event)
+	require.NoError(t, w.Start(events, make(chan error)))
+	defer w.Close()
+	<-events
+
+	jobNames := func() []string {
+		promCfg, err := w.CreatePromConfig("")
+		require.NoError(t, err)
+		var jobs []string
+		for _, scrapeConfig := range promCfg.ScrapeConfigs {
+			jobs = append(jobs, scrapeConfig.JobName)
+		}
+		return jobs
+	}
+	assert.ElementsMatch(t, []string{"serviceMonitor/team-a/sm-a/0", "serviceMonitor/team-b/sm-b/0"}, jobNames())
+
+	// the namespaces start being watched once they have to be selected
+	require.NoError(t, w.ApplyConfig(allocatorconfig.Config{
+		ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+		NamespaceSelector:      &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}},
+	}))
+	<-events
+	assert.ElementsMatch(t, []string{"serviceMonitor/team-b/sm-b/0"}, jobNames())
+
+	require.NoError(t, w.ApplyConfig(allocatorconfig.Config{
+		ServiceMonitorSelector: &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+	}))
+	<-events
+	assert.ElementsMatch(t, []string{"serviceMonitor/team-a/sm-a/0"}, jobNames())
+
+	// an invalid config keeps the current selectors
+	assert.Error(t, w.ApplyConfig(allocatorconfig.Config{
+		ServiceMonitorSelector: &allocatorconfig.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Invalid"}},
+		},
+	}))
+	assert.ElementsMatch(t, []string{"serviceMonitor/team-a/sm-a/0"}, jobNames())
+
+	// an unset selector selects nothing, unlike an empty one
+	require.NoError(t, w.ApplyConfig(allocatorconfig.Config{ServiceMonitorSelector: &allocatorconfig.LabelSelector{}}))
+	<-events
+	assert.Len(t, jobNames(), 2)
+	require.NoError(t, w.ApplyConfig(allocatorconfig.Config{}))
+	<-events
+	assert.Empty(t, jobNames())
+}
+
+func TestNamespacesNotListable(t *testing.T) {
+	monitors := []runtime.Object{
+		serviceMonitor("team-a", "sm-a", nil),
+	}
+	clientset := withMonitoringResources(fake.NewSimpleClientset(namespace("team-a", map[string]string{"tenant": "a"})), monitoringv1.ServiceMonitorName)
+	clientset.PrependReactor("list", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(v1.Resource("namespaces"), "", fmt.Errorf("forbidden"))
+	})
+	w, err := newPrometheusCRWatcher(logr.Discard(), fakemonitoring.NewSimpleClientset(monitors...), clientset, record.NewFakeRecorder(10), selectAll)
+	require.NoError(t, err)
+	w.debounceInterval = 10 * time.Millisecond
+	w.namespaceSyncTimeout = 100 * time.Millisecond
+
+	events := make(chan Event)
+	require.NoError(t, w.Start(events, make(chan error)))
+	defer w.Close()
+	<-events
+
+	// the config is still applied, without selecting any namespace
+	require.NoError(t, w.ApplyConfig(allocatorconfig.Config{
+		ServiceMonitorSelector: &allocatorconfig.LabelSelector{},
+		NamespaceSelector:      &allocatorconfig.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+	}))
+	promCfg, err := w.CreatePromConfig("")
+	require.NoError(t, err)
+	assert.Empty(t, promCfg.ScrapeConfigs)
+}
@@ -23,15 +23,26 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrlreconcile "sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/autodetect"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/reconcile"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/platform"
 )
 
 // OpenTelemetryCollectorReconciler reconciles a OpenTelemetryCollector object.
@@ -85,6 +96,16 @@ func NewReconciler(p Params) *OpenTelemetryCollectorReconciler {
 				reconcile.Deployments,
 				true,
 			},
+			{
+				"ingresses",
+				reconcile.Ingresses,
+				true,
+			},
+			{
+				"pod disruption budgets",
+				reconcile.PodDisruptionBudgets,
+				true,
+			},
 			{
 				"horizontal pod autoscalers",
 				reconcile.HorizontalPodAutoscalers,
@@ -151,6 +172,9 @@ func (r *OpenTelemetryCollectorReconciler) Reconcile(_ context.Context, req ctrl
 	}
 
 	if err := r.RunTasks(ctx, params); err != nil {
+		if statusErr := reconcile.Failure(ctx, params, err); statusErr != nil {
+			log.Error(statusErr, "failed to record the reconciliation failure in the status")
+		}
 		return ctrl.Result{}, err
 	}
 
@@ -173,14 +197,61 @@ func (r *OpenTelemetryCollectorReconciler) RunTasks(ctx context.Context, params
 
 // SetupWithManager tells the manager what our controller is interested in.
 func (r *OpenTelemetryCollectorReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.OpenTelemetryCollector{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.ServiceAccount{}).
 		Owns(&corev1.Service{}).
 		Owns(&appsv1.Deployment{}).
-		Owns(&autoscalingv1.HorizontalPodAutoscaler{}).
+		Owns(&autoscalingv1.HorizontalPodAutoscaler{})
+
+	// only the version served by the cluster can be watched
+	if r.config.PolicyVersion() == autodetect.PolicyV1Beta1 {
+		bldr = bldr.Owns(&policyv1beta1.PodDisruptionBudget{})
+	} else {
+		bldr = bldr.Owns(&policyv1.PodDisruptionBudget{})
+	}
+
+	// the routes are only served on OpenShift
+	if r.config.Platform() == platform.OpenShift {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(reconcile.RouteGVK)
+		bldr = bldr.Owns(route)
+	}
+
+	return bldr.
 		Owns(&appsv1.DaemonSet{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&networkingv1.Ingress{}).
+		// only the metadata of the secrets is cached, their data is read from the API server when hashing them
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.collectorsReferencing("secret")), builder.OnlyMetadata).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.collectorsReferencing("configmap"))).
 		Complete(r)
 }
+
+// collectorsReferencing returns a function mapping a secret or a config map, depending on the kind, to the
+// instances whose environment variables reference it, so that their pods are rolled out when it changes.
+func (r *OpenTelemetryCollectorReconciler) collectorsReferencing(kind string) handler.MapFunc {
+	return func(obj client.Object) []ctrlreconcile.Request {
+		list := &v1alpha1.OpenTelemetryCollectorList{}
+		if err := r.List(context.Background(), list, client.InNamespace(obj.GetNamespace())); err != nil {
+			r.log.Error(err, fmt.Sprintf("failed to list the instances referencing the %s", kind), "name", obj.GetName(), "namespace", obj.GetNamespace())
+			return nil
+		}
+
+		var requests []ctrlreconcile.Request
+		for _, instance := range list.Items {
+			names, configMaps := reconcile.ReferencedConfigSources(instance)
+			if kind == "configmap" {
+				names = configMaps
+			}
+			for _, name := range names {
+				if name == obj.GetName() {
+					requests = append(requests, ctrlreconcile.Request{NamespacedName: types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
@@ -48,6 +48,7 @@ type Config struct {
 	targetAllocatorImage           string
 	targetAllocatorConfigMapEntry  string
 	platform                       platform.Platform
+	policyVersion                  autodetect.PolicyVersion
 	autoInstrumentationJavaImage   string
 	autoInstrumentationNodeJSImage string
 	autoInstrumentationPythonImage string
@@ -63,6 +64,7 @@ func New(opts ...Option) Config {
 		targetAllocatorConfigMapEntry: defaultTargetAllocatorConfigMapEntry,
 		logger:                        logf.Log.WithName("config"),
 		platform:                      platform.Unknown,
+		policyVersion:                 autodetect.PolicyV1,
 		version:                       version.Get(),
 	}
 	for _, opt := range opts {
@@ -79,6 +81,7 @@ func New(opts ...Option) Config {
 		logger:                         o.logger,
 		onChange:                       o.onChange,
 		platform:                       o.platform,
+		policyVersion:                  o.policyVersion,
 		autoInstrumentationJavaImage:   o.autoInstrumentationJavaImage,
 		autoInstrumentationNodeJSImage: o.autoInstrumentationNodeJSImage,
 		autoInstrumentationPythonImage: o.autoInstrumentationPythonImage,
@@ -124,6 +127,16 @@ func (c *Config) AutoDetect() error {
 		}
 	}
 
+	policyVersion, err := c.autoDetect.PolicyVersion()
+	if err != nil {
+		return err
+	}
+	if c.policyVersion != policyVersion {
+		c.logger.V(1).Info("policy version detected", "version", policyVersion)
+		c.policyVersion = policyVersion
+		changed = true
+	}
+
 	if changed {
 		for _, callback := range c.onChange {
 			if err := callback(); err != nil {
@@ -162,6 +175,11 @@ func (c *Config) Platform() platform.Platform {
 	return c.platform
 }
 
+// PolicyVersion represents the version of the policy API group the PodDisruptionBudgets are served with.
+func (c *Config) PolicyVersion() autodetect.PolicyVersion {
+	return c.policyVersion
+}
+
 // AutoInstrumentationJavaImage returns OpenTelemetry Java auto-instrumentation container image.
 func (c *Config) AutoInstrumentationJavaImage() string {
 	return c.autoInstrumentationJavaImage
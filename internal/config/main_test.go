@@ -99,7 +99,15 @@ func TestAutoDetectInBackground(t *testing.T) {
 var _ autodetect.AutoDetect = (*mockAutoDetect)(nil)
 
 type mockAutoDetect struct {
-	PlatformFunc func() (platform.Platform, error)
+	PlatformFunc      func() (platform.Platform, error)
+	PolicyVersionFunc func() (autodetect.PolicyVersion, error)
+}
+
+func (m *mockAutoDetect) PolicyVersion() (autodetect.PolicyVersion, error) {
+	if m.PolicyVersionFunc != nil {
+		return m.PolicyVersionFunc()
+	}
+	return autodetect.PolicyV1, nil
 }
 
 func (m *mockAutoDetect) Platform() (platform.Platform, error) {
@@ -42,6 +42,7 @@ type options struct {
 	logger                         logr.Logger
 	onChange                       []func() error
 	platform                       platform.Platform
+	policyVersion                  autodetect.PolicyVersion
 	version                        version.Version
 	labelsFilter                   []string
 }
@@ -95,6 +96,11 @@ func WithPlatform(plt platform.Platform) Option {
 		o.platform = plt
 	}
 }
+func WithPolicyVersion(v autodetect.PolicyVersion) Option {
+	return func(o *options) {
+		o.policyVersion = v
+	}
+}
 func WithVersion(v version.Version) Option {
 	return func(o *options) {
 		o.version = v
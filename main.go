@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -32,6 +33,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -134,6 +136,11 @@ func main() {
 		config.WithLabelFilters(labelsFilter),
 	)
 
+	// the traits of the cluster decide which resources the controller watches, so they're detected before it's set up
+	if err = cfg.AutoDetect(); err != nil {
+		setupLog.Error(err, "failed to auto-detect the cluster traits, using the defaults")
+	}
+
 	watchNamespace, found := os.LookupEnv("WATCH_NAMESPACE")
 	if found {
 		setupLog.Info("watching namespace(s)", "namespaces", watchNamespace)
@@ -156,6 +163,8 @@ func main() {
 		LeaseDuration:          &leaseDuration,
 		RenewDeadline:          &renewDeadline,
 		RetryPeriod:            &retryPeriod,
+		// the secrets referenced by the collectors are read when hashing them, without caching all of them
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 	}
 
 	if strings.Contains(watchNamespace, ",") {
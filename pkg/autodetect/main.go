@@ -16,6 +16,7 @@
 package autodetect
 
 import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
 
@@ -27,8 +28,20 @@ var _ AutoDetect = (*autoDetect)(nil)
 // AutoDetect provides an assortment of routines that auto-detect traits based on the runtime.
 type AutoDetect interface {
 	Platform() (platform.Platform, error)
+	PolicyVersion() (PolicyVersion, error)
 }
 
+// PolicyVersion is the version of the policy API group the PodDisruptionBudgets are served with.
+type PolicyVersion string
+
+const (
+	// PolicyV1 is served by Kubernetes 1.21 and later.
+	PolicyV1 PolicyVersion = "v1"
+
+	// PolicyV1Beta1 is the only version served by Kubernetes before 1.21.
+	PolicyV1Beta1 PolicyVersion = "v1beta1"
+)
+
 type autoDetect struct {
 	dcl discovery.DiscoveryInterface
 }
@@ -64,3 +77,21 @@ func (a *autoDetect) Platform() (platform.Platform, error) {
 
 	return platform.Kubernetes, nil
 }
+
+// PolicyVersion returns the version of the policy API group serving the PodDisruptionBudgets.
+func (a *autoDetect) PolicyVersion() (PolicyVersion, error) {
+	resources, err := a.dcl.ServerResourcesForGroupVersion("policy/v1")
+	if apierrors.IsNotFound(err) {
+		return PolicyV1Beta1, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == "poddisruptionbudgets" {
+			return PolicyV1, nil
+		}
+	}
+	return PolicyV1Beta1, nil
+}
@@ -88,3 +88,51 @@ func TestUnknownPlatformOnError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, platform.Unknown, plt)
 }
+
+func TestDetectPolicyVersion(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		resources *metav1.APIResourceList
+		expected  autodetect.PolicyVersion
+	}{
+		{
+			name: "policy/v1 served",
+			resources: &metav1.APIResourceList{
+				GroupVersion: "policy/v1",
+				APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget", Namespaced: true}},
+			},
+			expected: autodetect.PolicyV1,
+		},
+		{
+			name:     "policy/v1 not served",
+			expected: autodetect.PolicyV1Beta1,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.resources == nil || req.URL.Path != "/apis/policy/v1" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				output, err := json.Marshal(tt.resources)
+				require.NoError(t, err)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, err = w.Write(output)
+				require.NoError(t, err)
+			}))
+			defer server.Close()
+
+			autoDetect, err := autodetect.New(&rest.Config{Host: server.URL})
+			require.NoError(t, err)
+
+			// test
+			version, err := autoDetect.PolicyVersion()
+
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
@@ -15,7 +15,14 @@
 package adapters
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/parser"
 )
 
 //Following Otel Doc: Configuring a receiver does not enable it. The receivers are enabled via pipelines within the service section.
@@ -109,3 +116,194 @@ func GetEnabledReceivers(logger logr.Logger, config map[interface{}]interface{})
 	}
 	return availableReceivers
 }
+
+// signals are the types of the pipelines.
+var signals = []string{"traces", "metrics", "logs"}
+
+// ConfigError is an error in the configuration, at the given path of its YAML.
+type ConfigError struct {
+	Path    string
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateConfig checks that the pipelines of the configuration are valid and reference defined components, that the
+// extensions of the service are defined and that no two enabled receivers listen on the same port. It returns every
+// error found, sorted by path. The sections missing from the configuration aren't checked.
+func ValidateConfig(logger logr.Logger, config map[interface{}]interface{}) []ConfigError {
+	v := configValidator{logger: logger, config: config}
+	v.validateService()
+	v.validateReceiverPorts()
+
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		return v.errs[i].Path < v.errs[j].Path
+	})
+	return v.errs
+}
+
+type configValidator struct {
+	logger logr.Logger
+	config map[interface{}]interface{}
+	// usedReceivers are the receivers referenced by the pipelines
+	usedReceivers map[string]bool
+	errs          []ConfigError
+}
+
+func (v *configValidator) errorf(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// components returns the components defined in the given section of the configuration.
+func (v *configValidator) components(section string) map[string]interface{} {
+	components := map[string]interface{}{}
+	property, ok := v.config[section]
+	if !ok || property == nil {
+		return components
+	}
+	m, ok := property.(map[interface{}]interface{})
+	if !ok {
+		v.errorf(section, "must be a map of components")
+		return components
+	}
+	for k, val := range m {
+		components[fmt.Sprint(k)] = val
+	}
+	return components
+}
+
+func (v *configValidator) validateService() {
+	property, ok := v.config["service"]
+	if !ok || property == nil {
+		return
+	}
+	service, ok := property.(map[interface{}]interface{})
+	if !ok {
+		v.errorf("service", "must be a map")
+		return
+	}
+
+	if extensions, ok := service["extensions"]; ok && extensions != nil {
+		v.validateReferences("service.extensions", extensions, "extension", v.components("extensions"))
+	}
+
+	property, ok = service["pipelines"]
+	if !ok || property == nil {
+		return
+	}
+	pipelines, ok := property.(map[interface{}]interface{})
+	if !ok {
+		v.errorf("service.pipelines", "must be a map of pipelines")
+		return
+	}
+
+	receivers := v.components("receivers")
+	processors := v.components("processors")
+	exporters := v.components("exporters")
+	v.usedReceivers = map[string]bool{}
+	for id, property := range pipelines {
+		path := fmt.Sprintf("service.pipelines.%v", id)
+		signal := strings.SplitN(fmt.Sprint(id), "/", 2)[0]
+		if !isSignal(signal) {
+			v.errorf(path, "the signal %q is invalid, it must be one of %s", signal, strings.Join(signals, ", "))
+		}
+
+		pipeline, ok := property.(map[interface{}]interface{})
+		if !ok {
+			v.errorf(path, "must be a map with the receivers, processors and exporters of the pipeline")
+			continue
+		}
+		for _, name := range v.validateReferences(path+".receivers", pipeline["receivers"], "receiver", receivers) {
+			v.usedReceivers[name] = true
+		}
+		if len(toList(pipeline["receivers"])) == 0 {
+			v.errorf(path+".receivers", "must reference at least one receiver")
+		}
+		v.validateReferences(path+".processors", pipeline["processors"], "processor", processors)
+		v.validateReferences(path+".exporters", pipeline["exporters"], "exporter", exporters)
+		if len(toList(pipeline["exporters"])) == 0 {
+			v.errorf(path+".exporters", "must reference at least one exporter")
+		}
+	}
+}
+
+// validateReferences checks that the list at the path only references defined components of the kind, and returns
+// the names it references.
+func (v *configValidator) validateReferences(path string, property interface{}, kind string, defined map[string]interface{}) []string {
+	if property == nil {
+		return nil
+	}
+	list, ok := property.([]interface{})
+	if !ok {
+		v.errorf(path, "must be a list of %ss", kind)
+		return nil
+	}
+	var names []string
+	for i, item := range list {
+		name, ok := item.(string)
+		if !ok {
+			v.errorf(fmt.Sprintf("%s[%d]", path, i), "must be the name of a %s", kind)
+			continue
+		}
+		if _, ok := defined[name]; !ok {
+			v.errorf(fmt.Sprintf("%s[%d]", path, i), "references the %s %q, which isn't defined in the %ss section", kind, name, kind)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// validateReceiverPorts checks that no two receivers enabled in a pipeline listen on the same port.
+func (v *configValidator) validateReceiverPorts() {
+	receivers := v.components("receivers")
+	names := make([]string, 0, len(v.usedReceivers))
+	for name := range v.usedReceivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type binding struct {
+		port     int32
+		protocol corev1.Protocol
+	}
+	bound := map[binding]string{}
+	for _, name := range names {
+		receiver, ok := receivers[name].(map[interface{}]interface{})
+		if !ok {
+			receiver = map[interface{}]interface{}{}
+		}
+		ports, err := parser.For(v.logger, name, receiver).Ports()
+		if err != nil {
+			v.errorf("receivers."+name, "%s", err)
+			continue
+		}
+		for _, port := range ports {
+			b := binding{port: port.Port, protocol: port.Protocol}
+			if len(b.protocol) == 0 {
+				b.protocol = corev1.ProtocolTCP
+			}
+			if other, ok := bound[b]; ok && other != name {
+				v.errorf("receivers."+name, "the %s port %d is already used by the receiver %q", b.protocol, b.port, other)
+				continue
+			}
+			bound[b] = name
+		}
+	}
+}
+
+func isSignal(signal string) bool {
+	for _, s := range signals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+func toList(property interface{}) []interface{} {
+	list, _ := property.([]interface{})
+	return list
+}
@@ -19,6 +19,7 @@ import (
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -105,3 +106,147 @@ service:
 	check := GetEnabledReceivers(logger, config)
 	require.Empty(t, check)
 }
+
+func TestValidateConfig(t *testing.T) {
+	for _, tt := range []struct {
+		desc     string
+		config   string
+		expected []string
+	}{
+		{
+			desc: "valid",
+			config: `
+extensions:
+  health_check:
+receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+  jaeger:
+    protocols:
+      grpc:
+  unused:
+    endpoint: 0.0.0.0:4317
+processors:
+  batch:
+exporters:
+  logging:
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      processors: [batch]
+      exporters: [logging]
+    metrics/otlp:
+      receivers: [otlp]
+      exporters: [logging]
+`,
+		},
+		{
+			desc: "without service",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+`,
+		},
+		{
+			desc: "undefined components",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+exporters:
+  logging:
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp, zipkin]
+      processors: [batch]
+      exporters: [logging, otlp/typo]
+`,
+			expected: []string{
+				`service.extensions[0]: references the extension "health_check", which isn't defined in the extensions section`,
+				`service.pipelines.traces.exporters[1]: references the exporter "otlp/typo", which isn't defined in the exporters section`,
+				`service.pipelines.traces.processors[0]: references the processor "batch", which isn't defined in the processors section`,
+				`service.pipelines.traces.receivers[1]: references the receiver "zipkin", which isn't defined in the receivers section`,
+			},
+		},
+		{
+			desc: "invalid pipelines",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+exporters:
+  logging:
+service:
+  pipelines:
+    trace:
+      receivers: [otlp]
+      exporters: [logging]
+    metrics:
+      receivers: otlp
+      exporters: []
+    logs: otlp
+`,
+			expected: []string{
+				"service.pipelines.logs: must be a map with the receivers, processors and exporters of the pipeline",
+				"service.pipelines.metrics.exporters: must reference at least one exporter",
+				"service.pipelines.metrics.receivers: must be a list of receivers",
+				"service.pipelines.metrics.receivers: must reference at least one receiver",
+				`service.pipelines.trace: the signal "trace" is invalid, it must be one of traces, metrics, logs`,
+			},
+		},
+		{
+			desc: "receivers on the same port",
+			config: `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+  otlp/2:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+  jaeger:
+    protocols:
+      thrift_compact:
+  jaeger/2:
+    protocols:
+      thrift_compact:
+exporters:
+  logging:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, otlp/2, jaeger, jaeger/2]
+      exporters: [logging]
+    metrics:
+      receivers: [otlp]
+      exporters: [logging]
+`,
+			expected: []string{
+				`receivers.jaeger/2: the UDP port 6831 is already used by the receiver "jaeger"`,
+				`receivers.otlp/2: the TCP port 4317 is already used by the receiver "otlp"`,
+			},
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			config, err := ConfigFromString(tt.config)
+			require.NoError(t, err)
+
+			var errs []string
+			for _, configErr := range ValidateConfig(logger, config) {
+				errs = append(errs, configErr.Error())
+			}
+			assert.Equal(t, tt.expected, errs)
+		})
+	}
+}
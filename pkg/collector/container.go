@@ -24,6 +24,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator"
 )
 
 // Container builds a container for the given collector.
@@ -63,6 +64,7 @@ func Container(cfg config.Config, logger logr.Logger, otelcol v1alpha1.OpenTelem
 			MountPath: "/usr/share/default-volume",
 		})
 	}
+	volumeMounts = append(volumeMounts, targetallocator.CollectorVolumeMounts(otelcol)...)
 
 	var envVars = otelcol.Spec.Env
 	if otelcol.Spec.Env == nil {
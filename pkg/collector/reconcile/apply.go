@@ -0,0 +1,274 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// FieldManager is the name of the field manager the operator applies the objects it owns with.
+const FieldManager = "opentelemetry-operator"
+
+// legacyFieldManager is the field manager the operator created and updated the objects with before applying them:
+// the name of its binary.
+const legacyFieldManager = "manager"
+
+// reconcileObjects applies the desired objects, then deletes the objects of the list's kind which belong to the
+// instance but aren't desired anymore.
+func reconcileObjects(ctx context.Context, params Params, list client.ObjectList, desired []client.Object) error {
+	// first, handle the create/update parts
+	if err := expectedObjects(ctx, params, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the expected objects: %w", err)
+	}
+
+	// then, delete the extra objects
+	if err := deleteObjects(ctx, params, list, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the objects to be deleted: %w", err)
+	}
+
+	return nil
+}
+
+// expectedObjects creates or updates the desired objects.
+func expectedObjects(ctx context.Context, params Params, desired []client.Object) error {
+	for _, obj := range desired {
+		if err := applyObject(ctx, params, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyObject creates or updates the object with server-side apply. The operator owns the fields set in the object:
+// they're changed back when changed by others, and removed once the operator stops setting them. The fields set by
+// other controllers, or by hand, are left alone. On success, the object holds the state of the cluster.
+func applyObject(ctx context.Context, params Params, obj client.Object) error {
+	if err := controllerutil.SetControllerReference(&params.Instance, obj, params.Scheme); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+
+	// the apply request must say what it applies, and must not hold the state of the cluster
+	gvk, err := apiutil.GVKForObject(obj, params.Scheme)
+	if err != nil {
+		return fmt.Errorf("failed to get the kind of %s: %w", obj.GetName(), err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	if err := upgradeManagedFields(ctx, params, obj); err != nil {
+		return fmt.Errorf("failed to upgrade the managed fields of the %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	defaultProtocols(obj)
+
+	if err := params.Client.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply the %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	params.Log.V(2).Info("applied", "object.kind", gvk.Kind, "object.name", obj.GetName(), "object.namespace", obj.GetNamespace())
+	return nil
+}
+
+// upgradeManagedFields hands the fields of the existing object owned by the legacy field manager over to FieldManager.
+// Otherwise, the legacy field manager keeps owning the fields the operator doesn't set anymore, and applying the object
+// doesn't remove them.
+func upgradeManagedFields(ctx context.Context, params Params, obj client.Object) error {
+	existing, ok := obj.DeepCopyObject().(client.Object)
+	if !ok {
+		return nil
+	}
+	if err := params.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing); k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	managedFields, upgraded, err := upgradedManagedFields(existing.GetManagedFields())
+	if err != nil || !upgraded {
+		return err
+	}
+	// the resource version fails the patch when the object changed in the meantime, the next reconciliation retries
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/resourceVersion", "value": existing.GetResourceVersion()},
+		{"op": "replace", "path": "/metadata/managedFields", "value": managedFields},
+	})
+	if err != nil {
+		return err
+	}
+	if err := params.Client.Patch(ctx, existing, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return err
+	}
+	params.Log.V(2).Info("upgraded the managed fields", "object.name", obj.GetName(), "object.namespace", obj.GetNamespace())
+	return nil
+}
+
+// upgradedManagedFields moves the fields owned by the updates of the legacy field manager to the apply entry of
+// FieldManager. It returns whether there was anything to move.
+func upgradedManagedFields(entries []metav1.ManagedFieldsEntry) ([]metav1.ManagedFieldsEntry, bool, error) {
+	legacy := &fieldpath.Set{}
+	var legacyEntry *metav1.ManagedFieldsEntry
+	var upgraded []metav1.ManagedFieldsEntry
+	applied := -1
+	for _, entry := range entries {
+		if entry.Subresource != "" {
+			upgraded = append(upgraded, entry)
+			continue
+		}
+		if entry.Manager == legacyFieldManager && entry.Operation == metav1.ManagedFieldsOperationUpdate {
+			fields, err := fieldSet(entry.FieldsV1)
+			if err != nil {
+				return nil, false, err
+			}
+			legacy = legacy.Union(fields)
+			if legacyEntry == nil {
+				legacyEntry = entry.DeepCopy()
+			}
+			continue
+		}
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			applied = len(upgraded)
+		}
+		upgraded = append(upgraded, entry)
+	}
+	if legacyEntry == nil {
+		return entries, false, nil
+	}
+
+	if applied == -1 {
+		upgraded = append(upgraded, metav1.ManagedFieldsEntry{
+			Manager:    FieldManager,
+			Operation:  metav1.ManagedFieldsOperationApply,
+			APIVersion: legacyEntry.APIVersion,
+			Time:       legacyEntry.Time,
+			FieldsType: "FieldsV1",
+		})
+		applied = len(upgraded) - 1
+	}
+	entry := &upgraded[applied]
+	fields, err := fieldSet(entry.FieldsV1)
+	if err != nil {
+		return nil, false, err
+	}
+	raw, err := fields.Union(legacy).ToJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+	return upgraded, true, nil
+}
+
+func fieldSet(fields *metav1.FieldsV1) (*fieldpath.Set, error) {
+	set := &fieldpath.Set{}
+	if fields == nil || len(fields.Raw) == 0 {
+		return set, nil
+	}
+	if err := set.FromJSON(bytes.NewReader(fields.Raw)); err != nil {
+		return nil, fmt.Errorf("failed to parse the managed fields: %w", err)
+	}
+	return set, nil
+}
+
+// deleteObjects deletes the objects of the list's kind which belong to the instance but aren't desired.
+func deleteObjects(ctx context.Context, params Params, list client.ObjectList, desired []client.Object) error {
+	opts := []client.ListOption{
+		client.InNamespace(params.Instance.Namespace),
+		client.MatchingLabels(map[string]string{
+			"app.kubernetes.io/instance":   fmt.Sprintf("%s.%s", params.Instance.Namespace, params.Instance.Name),
+			"app.kubernetes.io/managed-by": "opentelemetry-operator",
+		}),
+	}
+	if err := params.Client.List(ctx, list, opts...); err != nil {
+		return fmt.Errorf("failed to list: %w", err)
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return fmt.Errorf("failed to list: %w", err)
+	}
+	gvk, err := apiutil.GVKForObject(list, params.Scheme)
+	if err != nil {
+		return fmt.Errorf("failed to get the kind of the list: %w", err)
+	}
+	kind := strings.TrimSuffix(gvk.Kind, "List")
+
+	for _, item := range items {
+		existing, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		del := true
+		for _, keep := range desired {
+			if keep.GetName() == existing.GetName() && keep.GetNamespace() == existing.GetNamespace() {
+				del = false
+				break
+			}
+		}
+
+		if del {
+			if err := params.Client.Delete(ctx, existing); err != nil {
+				return fmt.Errorf("failed to delete: %w", err)
+			}
+			params.Log.V(2).Info("deleted", "object.kind", kind, "object.name", existing.GetName(), "object.namespace", existing.GetNamespace())
+		}
+	}
+
+	return nil
+}
+
+// defaultProtocols sets the protocol of the ports which don't have one. The protocol is part of the key of the port
+// lists, and older Kubernetes versions can't apply ports without their keys.
+func defaultProtocols(obj client.Object) {
+	switch o := obj.(type) {
+	case *corev1.Service:
+		// the ports may be shared with the instance's
+		ports := make([]corev1.ServicePort, len(o.Spec.Ports))
+		copy(ports, o.Spec.Ports)
+		for i := range ports {
+			if len(ports[i].Protocol) == 0 {
+				ports[i].Protocol = corev1.ProtocolTCP
+			}
+		}
+		o.Spec.Ports = ports
+	case *appsv1.Deployment:
+		defaultContainerProtocols(&o.Spec.Template.Spec)
+	case *appsv1.DaemonSet:
+		defaultContainerProtocols(&o.Spec.Template.Spec)
+	case *appsv1.StatefulSet:
+		defaultContainerProtocols(&o.Spec.Template.Spec)
+	}
+}
+
+func defaultContainerProtocols(spec *corev1.PodSpec) {
+	for i := range spec.Containers {
+		for j := range spec.Containers[i].Ports {
+			if len(spec.Containers[i].Ports[j].Protocol) == 0 {
+				spec.Containers[i].Ports[j].Protocol = corev1.ProtocolTCP
+			}
+		}
+	}
+}
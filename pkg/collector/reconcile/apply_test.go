@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefaultProtocols(t *testing.T) {
+	t.Run("should default the ports of the service without changing the instance's", func(t *testing.T) {
+		instancePorts := []v1.ServicePort{{Name: "web", Port: 80}, {Name: "dns", Port: 53, Protocol: v1.ProtocolUDP}}
+		svc := &v1.Service{Spec: v1.ServiceSpec{Ports: instancePorts}}
+
+		defaultProtocols(svc)
+
+		assert.Equal(t, v1.ProtocolTCP, svc.Spec.Ports[0].Protocol)
+		assert.Equal(t, v1.ProtocolUDP, svc.Spec.Ports[1].Protocol)
+		assert.Empty(t, instancePorts[0].Protocol)
+	})
+
+	t.Run("should default the container ports of the deployment", func(t *testing.T) {
+		d := &appsv1.Deployment{}
+		d.Spec.Template.Spec.Containers = []v1.Container{{Name: "otc-container", Ports: []v1.ContainerPort{{Name: "otlp", ContainerPort: 4317}}}}
+
+		defaultProtocols(d)
+
+		assert.Equal(t, v1.ProtocolTCP, d.Spec.Template.Spec.Containers[0].Ports[0].Protocol)
+	})
+}
+
+func TestUpgradedManagedFields(t *testing.T) {
+	legacy := metav1.ManagedFieldsEntry{
+		Manager:    legacyFieldManager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:ports":{"k:{\"port\":8080,\"protocol\":\"TCP\"}":{}}}}`)},
+	}
+	other := metav1.ManagedFieldsEntry{
+		Manager:    "kubectl-edit",
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:team":{}}}}`)},
+	}
+	applied := metav1.ManagedFieldsEntry{
+		Manager:    FieldManager,
+		Operation:  metav1.ManagedFieldsOperationApply,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:ports":{"k:{\"port\":4317,\"protocol\":\"TCP\"}":{}}}}`)},
+	}
+
+	t.Run("should leave the fields alone without the legacy field manager", func(t *testing.T) {
+		entries := []metav1.ManagedFieldsEntry{other, applied}
+		upgraded, changed, err := upgradedManagedFields(entries)
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, entries, upgraded)
+	})
+
+	t.Run("should hand the fields over to a new apply entry", func(t *testing.T) {
+		upgraded, changed, err := upgradedManagedFields([]metav1.ManagedFieldsEntry{legacy, other})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		require.Len(t, upgraded, 2)
+		assert.Equal(t, other, upgraded[0])
+		assert.Equal(t, FieldManager, upgraded[1].Manager)
+		assert.Equal(t, metav1.ManagedFieldsOperationApply, upgraded[1].Operation)
+		assert.Equal(t, "v1", upgraded[1].APIVersion)
+		assert.JSONEq(t, string(legacy.FieldsV1.Raw), string(upgraded[1].FieldsV1.Raw))
+	})
+
+	t.Run("should merge the fields into the existing apply entry", func(t *testing.T) {
+		upgraded, changed, err := upgradedManagedFields([]metav1.ManagedFieldsEntry{legacy, applied})
+		require.NoError(t, err)
+		assert.True(t, changed)
+		require.Len(t, upgraded, 1)
+		assert.Equal(t, FieldManager, upgraded[0].Manager)
+		assert.JSONEq(t, `{"f:spec":{"f:ports":{"k:{\"port\":4317,\"protocol\":\"TCP\"}":{},"k:{\"port\":8080,\"protocol\":\"TCP\"}":{}}}}`, string(upgraded[0].FieldsV1.Raw))
+	})
+}
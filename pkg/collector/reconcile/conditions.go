@@ -0,0 +1,272 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+	ta "github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator/adapters"
+)
+
+// The reasons of the status conditions.
+const (
+	reasonAvailable       = "Available"
+	reasonNotAvailable    = "NotAvailable"
+	reasonNotFound        = "NotFound"
+	reasonRollingOut      = "RollingOut"
+	reasonRolloutComplete = "RolloutComplete"
+	reasonRolloutFailed   = "RolloutFailed"
+	reasonSidecar         = "Sidecar"
+	reasonAsExpected      = "AsExpected"
+	reasonConfigParsed    = "ConfigParsed"
+	reasonInvalidConfig   = "InvalidConfig"
+	reasonReconcileFailed = "ReconcileFailed"
+)
+
+// workloadStatus summarizes the rollout of a Deployment, DaemonSet or StatefulSet.
+type workloadStatus struct {
+	kind  string
+	found bool
+	// observed is false until the workload's controller processed its latest spec
+	observed bool
+	// desired is the number of pods which should run, current the number of pods running, updated the number of
+	// pods running the latest spec and available the number of those which are available
+	desired, current, updated, available int32
+	// failure describes why the rollout failed, empty unless it did
+	failure string
+}
+
+func deploymentStatus(d *appsv1.Deployment) workloadStatus {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	s := workloadStatus{
+		kind:      "deployment",
+		found:     true,
+		observed:  d.Status.ObservedGeneration >= d.Generation,
+		desired:   desired,
+		current:   d.Status.Replicas,
+		updated:   d.Status.UpdatedReplicas,
+		available: d.Status.AvailableReplicas,
+	}
+	for _, c := range d.Status.Conditions {
+		if (c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded") ||
+			(c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue) {
+			s.failure = c.Message
+		}
+	}
+	return s
+}
+
+func statefulSetStatus(ss *appsv1.StatefulSet) workloadStatus {
+	desired := int32(1)
+	if ss.Spec.Replicas != nil {
+		desired = *ss.Spec.Replicas
+	}
+	return workloadStatus{
+		kind:     "statefulset",
+		found:    true,
+		observed: ss.Status.ObservedGeneration >= ss.Generation,
+		desired:  desired,
+		current:  ss.Status.Replicas,
+		updated:  ss.Status.UpdatedReplicas,
+		// the available replicas aren't reported by all the supported Kubernetes versions
+		available: ss.Status.ReadyReplicas,
+	}
+}
+
+func daemonSetStatus(ds *appsv1.DaemonSet) workloadStatus {
+	return workloadStatus{
+		kind:      "daemonset",
+		found:     true,
+		observed:  ds.Status.ObservedGeneration >= ds.Generation,
+		desired:   ds.Status.DesiredNumberScheduled,
+		current:   ds.Status.CurrentNumberScheduled,
+		updated:   ds.Status.UpdatedNumberScheduled,
+		available: ds.Status.NumberAvailable,
+	}
+}
+
+// complete returns whether all the desired pods run the latest spec and are available, and no other pod is left.
+func (s workloadStatus) complete() bool {
+	return s.found && s.observed && s.updated >= s.desired && s.current <= s.updated && s.available >= s.desired
+}
+
+func (s workloadStatus) message() string {
+	if !s.found {
+		return fmt.Sprintf("the %s doesn't exist yet", s.kind)
+	}
+	return fmt.Sprintf("%d of %d pods of the %s updated, %d available", s.updated, s.desired, s.kind, s.available)
+}
+
+// readyCondition returns whether the workload is ready, as a condition of the given type.
+func (s workloadStatus) readyCondition(conditionType string) metav1.Condition {
+	switch {
+	case s.complete():
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionTrue, Reason: reasonAvailable, Message: s.message()}
+	case !s.found:
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: reasonNotFound, Message: s.message()}
+	case len(s.failure) > 0:
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: reasonRolloutFailed, Message: s.failure}
+	default:
+		return metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: reasonNotAvailable, Message: s.message()}
+	}
+}
+
+func (s workloadStatus) progressingCondition() metav1.Condition {
+	switch {
+	case s.complete():
+		return metav1.Condition{Type: v1alpha1.ConditionTypeProgressing, Status: metav1.ConditionFalse, Reason: reasonRolloutComplete, Message: s.message()}
+	case len(s.failure) > 0:
+		return metav1.Condition{Type: v1alpha1.ConditionTypeProgressing, Status: metav1.ConditionFalse, Reason: reasonRolloutFailed, Message: s.failure}
+	default:
+		return metav1.Condition{Type: v1alpha1.ConditionTypeProgressing, Status: metav1.ConditionTrue, Reason: reasonRollingOut, Message: s.message()}
+	}
+}
+
+// getWorkloadStatus returns the status of the collector's workload, nil in sidecar mode.
+func getWorkloadStatus(ctx context.Context, cli client.Client, otelcol v1alpha1.OpenTelemetryCollector) (*workloadStatus, error) {
+	key := client.ObjectKey{Namespace: otelcol.Namespace, Name: naming.Collector(otelcol)}
+
+	var (
+		status workloadStatus
+		err    error
+	)
+	switch otelcol.Spec.Mode {
+	case v1alpha1.ModeSidecar:
+		return nil, nil
+	case v1alpha1.ModeDaemonSet:
+		obj := &appsv1.DaemonSet{}
+		if err = cli.Get(ctx, key, obj); err == nil {
+			status = daemonSetStatus(obj)
+		}
+		status.kind = "daemonset"
+	case v1alpha1.ModeStatefulSet:
+		obj := &appsv1.StatefulSet{}
+		if err = cli.Get(ctx, key, obj); err == nil {
+			status = statefulSetStatus(obj)
+		}
+		status.kind = "statefulset"
+	default:
+		obj := &appsv1.Deployment{}
+		if err = cli.Get(ctx, key, obj); err == nil {
+			status = deploymentStatus(obj)
+		}
+		status.kind = "deployment"
+	}
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get the %s: %w", status.kind, err)
+	}
+	return &status, nil
+}
+
+// getTargetAllocatorStatus returns the status of the target allocator's deployment.
+func getTargetAllocatorStatus(ctx context.Context, cli client.Client, otelcol v1alpha1.OpenTelemetryCollector) (workloadStatus, error) {
+	obj := &appsv1.Deployment{}
+	key := client.ObjectKey{Namespace: otelcol.Namespace, Name: naming.TargetAllocator(otelcol)}
+	if err := cli.Get(ctx, key, obj); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return workloadStatus{kind: "deployment"}, nil
+		}
+		return workloadStatus{}, fmt.Errorf("failed to get the target allocator deployment: %w", err)
+	}
+	return deploymentStatus(obj), nil
+}
+
+// validateConfig parses the collector's configuration the same way the reconciliation does.
+func validateConfig(params Params) error {
+	if _, err := adapters.ConfigFromString(params.Instance.Spec.Config); err != nil {
+		return err
+	}
+	if params.Instance.Spec.TargetAllocator.Enabled {
+		if _, err := ta.ConfigToPromConfig(params.Instance.Spec.Config); err != nil {
+			return err
+		}
+		if _, err := ReplaceConfig(params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func configValidCondition(configErr error) metav1.Condition {
+	if configErr != nil {
+		return metav1.Condition{Type: v1alpha1.ConditionTypeConfigValid, Status: metav1.ConditionFalse, Reason: reasonInvalidConfig, Message: configErr.Error()}
+	}
+	return metav1.Condition{Type: v1alpha1.ConditionTypeConfigValid, Status: metav1.ConditionTrue, Reason: reasonConfigParsed}
+}
+
+// updateConditions sets the conditions of the instance from the validity of its configuration and the status of its
+// workloads.
+func updateConditions(ctx context.Context, params Params, changed *v1alpha1.OpenTelemetryCollector) error {
+	configErr := validateConfig(params)
+	conditions := []metav1.Condition{configValidCondition(configErr)}
+
+	workload, err := getWorkloadStatus(ctx, params.Client, *changed)
+	if err != nil {
+		return err
+	}
+	if workload == nil {
+		message := "the collector is injected as a sidecar into the pods requesting it"
+		conditions = append(conditions,
+			metav1.Condition{Type: v1alpha1.ConditionTypeReady, Status: metav1.ConditionTrue, Reason: reasonSidecar, Message: message},
+			metav1.Condition{Type: v1alpha1.ConditionTypeProgressing, Status: metav1.ConditionFalse, Reason: reasonSidecar, Message: message},
+		)
+	} else {
+		conditions = append(conditions, workload.readyCondition(v1alpha1.ConditionTypeReady), workload.progressingCondition())
+	}
+
+	switch {
+	case configErr != nil:
+		conditions = append(conditions, metav1.Condition{Type: v1alpha1.ConditionTypeDegraded, Status: metav1.ConditionTrue, Reason: reasonInvalidConfig, Message: configErr.Error()})
+	case workload != nil && len(workload.failure) > 0:
+		conditions = append(conditions, metav1.Condition{Type: v1alpha1.ConditionTypeDegraded, Status: metav1.ConditionTrue, Reason: reasonRolloutFailed, Message: workload.failure})
+	default:
+		conditions = append(conditions, metav1.Condition{Type: v1alpha1.ConditionTypeDegraded, Status: metav1.ConditionFalse, Reason: reasonAsExpected})
+	}
+
+	if changed.Spec.TargetAllocator.Enabled {
+		targetAllocator, err := getTargetAllocatorStatus(ctx, params.Client, *changed)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, targetAllocator.readyCondition(v1alpha1.ConditionTypeTargetAllocatorReady))
+	} else {
+		meta.RemoveStatusCondition(&changed.Status.Conditions, v1alpha1.ConditionTypeTargetAllocatorReady)
+	}
+
+	setConditions(changed, conditions...)
+	return nil
+}
+
+// setConditions sets the conditions, only changing their transition time when their status changes.
+func setConditions(changed *v1alpha1.OpenTelemetryCollector, conditions ...metav1.Condition) {
+	for _, condition := range conditions {
+		condition.ObservedGeneration = changed.Generation
+		meta.SetStatusCondition(&changed.Status.Conditions, condition)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+)
+
+func TestWorkloadConditions(t *testing.T) {
+	for _, tt := range []struct {
+		desc        string
+		status      workloadStatus
+		ready       metav1.ConditionStatus
+		readyReason string
+		progressing metav1.ConditionStatus
+	}{
+		{
+			desc:        "not created yet",
+			status:      workloadStatus{kind: "deployment"},
+			ready:       metav1.ConditionFalse,
+			readyReason: reasonNotFound,
+			progressing: metav1.ConditionTrue,
+		},
+		{
+			desc:        "rolled out",
+			status:      workloadStatus{found: true, observed: true, desired: 2, current: 2, updated: 2, available: 2},
+			ready:       metav1.ConditionTrue,
+			readyReason: reasonAvailable,
+			progressing: metav1.ConditionFalse,
+		},
+		{
+			desc:        "new spec not observed yet",
+			status:      workloadStatus{found: true, desired: 2, current: 2, updated: 2, available: 2},
+			ready:       metav1.ConditionFalse,
+			readyReason: reasonNotAvailable,
+			progressing: metav1.ConditionTrue,
+		},
+		{
+			desc:        "old pods left",
+			status:      workloadStatus{found: true, observed: true, desired: 2, current: 3, updated: 2, available: 3},
+			ready:       metav1.ConditionFalse,
+			readyReason: reasonNotAvailable,
+			progressing: metav1.ConditionTrue,
+		},
+		{
+			desc:        "pods not available",
+			status:      workloadStatus{found: true, observed: true, desired: 2, current: 2, updated: 2, available: 1},
+			ready:       metav1.ConditionFalse,
+			readyReason: reasonNotAvailable,
+			progressing: metav1.ConditionTrue,
+		},
+		{
+			desc:        "rollout failed",
+			status:      workloadStatus{found: true, observed: true, desired: 2, current: 2, updated: 1, available: 1, failure: "deadline exceeded"},
+			ready:       metav1.ConditionFalse,
+			readyReason: reasonRolloutFailed,
+			progressing: metav1.ConditionFalse,
+		},
+		{
+			desc:        "scaled to zero",
+			status:      workloadStatus{found: true, observed: true},
+			ready:       metav1.ConditionTrue,
+			readyReason: reasonAvailable,
+			progressing: metav1.ConditionFalse,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			ready := tt.status.readyCondition(v1alpha1.ConditionTypeReady)
+			assert.Equal(t, tt.ready, ready.Status)
+			assert.Equal(t, tt.readyReason, ready.Reason)
+			assert.Equal(t, tt.progressing, tt.status.progressingCondition().Status)
+		})
+	}
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	replicas := int32(3)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  2,
+			Conditions: []appsv1.DeploymentCondition{{
+				Type:    appsv1.DeploymentProgressing,
+				Status:  v1.ConditionFalse,
+				Reason:  "ProgressDeadlineExceeded",
+				Message: `ReplicaSet "test-collector-5d4f" has timed out progressing.`,
+			}},
+		},
+	}
+
+	status := deploymentStatus(d)
+	assert.Equal(t, workloadStatus{
+		kind:      "deployment",
+		found:     true,
+		observed:  true,
+		desired:   3,
+		current:   3,
+		updated:   1,
+		available: 2,
+		failure:   `ReplicaSet "test-collector-5d4f" has timed out progressing.`,
+	}, status)
+}
+
+func TestValidateConfig(t *testing.T) {
+	param := params()
+	assert.NoError(t, validateConfig(param))
+
+	param.Instance.Spec.Config = "receivers: ["
+	assert.Error(t, validateConfig(param))
+
+	param, err := newParams("test/test-img", "")
+	require.NoError(t, err)
+	assert.NoError(t, validateConfig(param))
+
+	param.Instance.Spec.Config = `receivers:
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+  prometheus/infra:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+`
+	assert.Error(t, validateConfig(param))
+}
+
+func TestUpdateConditions(t *testing.T) {
+	param := params()
+	param.Instance.Name = "conditions"
+	param.Instance.Spec.Mode = v1alpha1.ModeDeployment
+
+	t.Run("should not be ready before the deployment is created", func(t *testing.T) {
+		changed := param.Instance.DeepCopy()
+		require.NoError(t, updateConditions(context.Background(), param, changed))
+
+		conditions := changed.Status.Conditions
+		assert.True(t, meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionTypeConfigValid))
+		assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionTypeReady))
+		assert.True(t, meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionTypeProgressing))
+		assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionTypeDegraded))
+		assert.Nil(t, meta.FindStatusCondition(conditions, v1alpha1.ConditionTypeTargetAllocatorReady))
+	})
+
+	t.Run("should be ready once the deployment is available", func(t *testing.T) {
+		labels := map[string]string{"app": "conditions"}
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: naming.Collector(param.Instance), Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: param.Instance.Spec.Replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "otc-container", Image: "otel/opentelemetry-collector"}}},
+				},
+			},
+		}
+		createObjectIfNotExists(t, deployment.Name, deployment)
+		deployment.Status = appsv1.DeploymentStatus{
+			ObservedGeneration: deployment.Generation,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			ReadyReplicas:      2,
+			AvailableReplicas:  2,
+		}
+		require.NoError(t, k8sClient.Status().Update(context.Background(), deployment))
+
+		changed := param.Instance.DeepCopy()
+		require.NoError(t, updateConditions(context.Background(), param, changed))
+
+		conditions := changed.Status.Conditions
+		assert.True(t, meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionTypeReady))
+		assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionTypeProgressing))
+		assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionTypeDegraded))
+	})
+
+	t.Run("should report the target allocator when it is enabled", func(t *testing.T) {
+		taParam := param
+		taParam.Instance.Spec.TargetAllocator.Enabled = true
+		changed := taParam.Instance.DeepCopy()
+		require.NoError(t, updateConditions(context.Background(), taParam, changed))
+
+		condition := meta.FindStatusCondition(changed.Status.Conditions, v1alpha1.ConditionTypeTargetAllocatorReady)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, reasonNotFound, condition.Reason)
+
+		// the condition is removed once the target allocator is disabled
+		changed.Spec.TargetAllocator.Enabled = false
+		require.NoError(t, updateConditions(context.Background(), param, changed))
+		assert.Nil(t, meta.FindStatusCondition(changed.Status.Conditions, v1alpha1.ConditionTypeTargetAllocatorReady))
+	})
+
+	t.Run("should be ready in sidecar mode", func(t *testing.T) {
+		sidecarParam := param
+		sidecarParam.Instance.Spec.Mode = v1alpha1.ModeSidecar
+		changed := sidecarParam.Instance.DeepCopy()
+		require.NoError(t, updateConditions(context.Background(), sidecarParam, changed))
+
+		ready := meta.FindStatusCondition(changed.Status.Conditions, v1alpha1.ConditionTypeReady)
+		require.NotNil(t, ready)
+		assert.Equal(t, metav1.ConditionTrue, ready.Status)
+		assert.Equal(t, reasonSidecar, ready.Reason)
+	})
+
+	t.Run("should report an invalid config", func(t *testing.T) {
+		invalidParam := param
+		invalidParam.Instance.Spec.Config = "receivers: ["
+		changed := invalidParam.Instance.DeepCopy()
+		require.NoError(t, updateConditions(context.Background(), invalidParam, changed))
+
+		conditions := changed.Status.Conditions
+		assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionTypeConfigValid))
+		degraded := meta.FindStatusCondition(conditions, v1alpha1.ConditionTypeDegraded)
+		require.NotNil(t, degraded)
+		assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+		assert.Equal(t, reasonInvalidConfig, degraded.Reason)
+	})
+}
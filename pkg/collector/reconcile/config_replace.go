@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/mitchellh/mapstructure"
+	commonconfig "github.com/prometheus/common/config"
 	promconfig "github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/discovery/http"
@@ -25,7 +26,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
-	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator"
 	ta "github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator/adapters"
 )
 
@@ -42,39 +43,88 @@ func ReplaceConfig(params Params) (string, error) {
 		return "", err
 	}
 
-	promCfgMap, err := ta.ConfigToPromConfig(params.Instance.Spec.Config)
+	promCfgMaps, err := ta.ConfigToPromConfigs(params.Instance.Spec.Config)
 	if err != nil {
 		return "", err
 	}
+	// type coercion checks are handled in the ConfigToPromConfigs method above
+	receivers := config["receivers"].(map[interface{}]interface{})
 
+	if params.Instance.Spec.TargetAllocator.PrometheusCR.Enabled {
+		if len(promCfgMaps) == 1 {
+			name := ta.PrometheusReceiverNames(promCfgMaps)[0]
+			return replaceConfigWithTargetAllocator(params, config, receivers[name].(map[interface{}]interface{}), promCfgMaps[name])
+		}
+		// rejected by the webhook, the receivers keep scraping their own jobs rather than failing the reconciliation
+		params.Log.Info("The jobs of the Prometheus CRs aren't scraped", "reason", ta.ErrPrometheusCRMultipleReceivers.Error())
+	}
+
+	for name, promCfgMap := range promCfgMaps {
+		updPromCfg, err := replaceServiceDiscoveryConfigs(params, promCfgMap)
+		if err != nil {
+			return "", err
+		}
+		receivers[name].(map[interface{}]interface{})["config"] = updPromCfg
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// replaceServiceDiscoveryConfigs replaces the service discovery configs of every job of the Prometheus config with
+// an http_sd_config retrieving the targets the TargetAllocator allocated to the collector. Each job needs its own
+// http_sd_config returning a list of target groups, so the collectors can't use the TargetAllocator's
+// /collectors/{id}/targets endpoint, which returns the targets of all jobs at once.
+func replaceServiceDiscoveryConfigs(params Params, promCfgMap map[interface{}]interface{}) (interface{}, error) {
 	// yaml marshaling/unsmarshaling is preferred because of the problems associated with the conversion of map to a struct using mapstructure
 	promCfg, err := yaml.Marshal(map[string]interface{}{
 		"config": promCfgMap,
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var cfg Config
 	if err = yaml.UnmarshalStrict(promCfg, &cfg); err != nil {
-		return "", fmt.Errorf("error unmarshaling YAML: %w", err)
+		return nil, fmt.Errorf("error unmarshaling YAML: %w", err)
 	}
 
 	for i := range cfg.PromConfig.ScrapeConfigs {
 		cfg.PromConfig.ScrapeConfigs[i].ServiceDiscoveryConfigs = discovery.Configs{
 			&http.SDConfig{
-				URL: fmt.Sprintf("http://%s:80/jobs/%s/targets?collector_id=$POD_NAME", naming.TAService(params.Instance), cfg.PromConfig.ScrapeConfigs[i].JobName),
+				HTTPClientConfig: targetAllocatorHTTPClientConfig(params),
+				URL:              fmt.Sprintf("%s/jobs/%s/targets?collector_id=$POD_NAME", targetallocator.Endpoint(params.Instance), cfg.PromConfig.ScrapeConfigs[i].JobName),
 			},
 		}
 	}
 
 	updPromCfgMap := make(map[string]interface{})
 	if err := mapstructure.Decode(cfg, &updPromCfgMap); err != nil {
-		return "", err
+		return nil, err
 	}
+	return updPromCfgMap["PromConfig"], nil
+}
 
-	// type coercion checks are handled in the ConfigToPromConfig method above
-	config["receivers"].(map[interface{}]interface{})["prometheus"].(map[interface{}]interface{})["config"] = updPromCfgMap["PromConfig"]
+// replaceConfigWithTargetAllocator configures the prometheus receiver to retrieve its scrape configs and targets
+// from the TargetAllocator. Unlike the http_sd_configs, this includes the jobs generated from the Prometheus CRs,
+// which aren't part of the collector's configuration.
+func replaceConfigWithTargetAllocator(params Params, config map[interface{}]interface{}, prometheus map[interface{}]interface{}, promCfgMap map[interface{}]interface{}) (string, error) {
+	// the scrape configs are served by the TargetAllocator, keep everything else, like the global section
+	delete(promCfgMap, "scrape_configs")
+
+	prometheus["config"] = promCfgMap
+	targetAllocatorCfg := map[interface{}]interface{}{
+		"endpoint":     targetallocator.Endpoint(params.Instance),
+		"interval":     "30s",
+		"collector_id": "$POD_NAME",
+	}
+	if httpSDConfig := targetAllocatorHTTPSDConfig(params); len(httpSDConfig) > 0 {
+		targetAllocatorCfg["http_sd_config"] = httpSDConfig
+	}
+	prometheus["target_allocator"] = targetAllocatorCfg
 
 	out, err := yaml.Marshal(config)
 	if err != nil {
@@ -82,3 +132,50 @@ func ReplaceConfig(params Params) (string, error) {
 	}
 	return string(out), nil
 }
+
+// targetAllocatorHTTPClientConfig returns the settings the collectors use to connect to a TargetAllocator
+// secured with TLS or a bearer token. The files are mounted by targetallocator.CollectorVolumeMounts.
+func targetAllocatorHTTPClientConfig(params Params) commonconfig.HTTPClientConfig {
+	var httpCfg commonconfig.HTTPClientConfig
+	if tls := params.Instance.Spec.TargetAllocator.TLS; tls != nil {
+		httpCfg.TLSConfig.CAFile = targetallocator.CollectorCAFile
+		if len(tls.ClientCertSecretName) > 0 {
+			httpCfg.TLSConfig.CertFile = targetallocator.CollectorCertFile
+			httpCfg.TLSConfig.KeyFile = targetallocator.CollectorKeyFile
+		}
+	}
+	if params.Instance.Spec.TargetAllocator.BearerTokenSecret != nil {
+		httpCfg.Authorization = &commonconfig.Authorization{
+			Type:            "Bearer",
+			CredentialsFile: targetallocator.CollectorBearerTokenFile,
+		}
+	}
+	return httpCfg
+}
+
+// targetAllocatorHTTPSDConfig returns the same settings as targetAllocatorHTTPClientConfig, in the form of the
+// receiver's target_allocator.http_sd_config section. Only the fields which are set are included, so that
+// the defaults of the receiver, like following redirects, are kept.
+func targetAllocatorHTTPSDConfig(params Params) map[interface{}]interface{} {
+	httpCfg := targetAllocatorHTTPClientConfig(params)
+	httpSDConfig := map[interface{}]interface{}{}
+
+	tlsConfig := map[interface{}]interface{}{}
+	if len(httpCfg.TLSConfig.CAFile) > 0 {
+		tlsConfig["ca_file"] = httpCfg.TLSConfig.CAFile
+	}
+	if len(httpCfg.TLSConfig.CertFile) > 0 {
+		tlsConfig["cert_file"] = httpCfg.TLSConfig.CertFile
+		tlsConfig["key_file"] = httpCfg.TLSConfig.KeyFile
+	}
+	if len(tlsConfig) > 0 {
+		httpSDConfig["tls_config"] = tlsConfig
+	}
+	if httpCfg.Authorization != nil {
+		httpSDConfig["authorization"] = map[interface{}]interface{}{
+			"type":             httpCfg.Authorization.Type,
+			"credentials_file": httpCfg.Authorization.CredentialsFile,
+		}
+	}
+	return httpSDConfig
+}
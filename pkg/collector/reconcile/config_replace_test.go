@@ -20,7 +20,10 @@ import (
 	"github.com/prometheus/prometheus/discovery/http"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
 
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
 	ta "github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator/adapters"
 )
 
@@ -95,4 +98,147 @@ func TestPrometheusParser(t *testing.T) {
 		}
 	})
 
+	t.Run("should use the target allocator for the scrape configs when the Prometheus CRs are enabled", func(t *testing.T) {
+		param.Instance.Spec.TargetAllocator.Enabled = true
+		param.Instance.Spec.TargetAllocator.PrometheusCR.Enabled = true
+		actualConfig, err := ReplaceConfig(param)
+		assert.NoError(t, err)
+
+		// prepare
+		cfg, err := adapters.ConfigFromString(actualConfig)
+		assert.NoError(t, err)
+		prometheus := cfg["receivers"].(map[interface{}]interface{})["prometheus"].(map[interface{}]interface{})
+
+		// test
+		assert.Equal(t, map[interface{}]interface{}{
+			"endpoint":     "http://test-targetallocator:80",
+			"interval":     "30s",
+			"collector_id": "$POD_NAME",
+		}, prometheus["target_allocator"])
+		promCfgMap, err := ta.ConfigToPromConfig(actualConfig)
+		assert.NoError(t, err)
+		assert.NotContains(t, promCfgMap, "scrape_configs")
+	})
+
+	t.Run("should configure the target allocator with TLS and a bearer token", func(t *testing.T) {
+		param.Instance.Spec.TargetAllocator.TLS = &v1alpha1.OpenTelemetryTargetAllocatorTLS{
+			SecretName:           "ta-cert",
+			ClientCertSecretName: "collector-cert",
+		}
+		param.Instance.Spec.TargetAllocator.BearerTokenSecret = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "ta-token"},
+			Key:                  "token",
+		}
+		defer func() {
+			param.Instance.Spec.TargetAllocator.TLS = nil
+			param.Instance.Spec.TargetAllocator.BearerTokenSecret = nil
+		}()
+		actualConfig, err := ReplaceConfig(param)
+		assert.NoError(t, err)
+
+		// prepare
+		cfg, err := adapters.ConfigFromString(actualConfig)
+		assert.NoError(t, err)
+		prometheus := cfg["receivers"].(map[interface{}]interface{})["prometheus"].(map[interface{}]interface{})
+
+		// test
+		assert.Equal(t, map[interface{}]interface{}{
+			"endpoint":     "https://test-targetallocator:443",
+			"interval":     "30s",
+			"collector_id": "$POD_NAME",
+			"http_sd_config": map[interface{}]interface{}{
+				"tls_config": map[interface{}]interface{}{
+					"ca_file":   "/etc/otel-targetallocator/tls/ca.crt",
+					"cert_file": "/etc/otel-targetallocator/client/tls.crt",
+					"key_file":  "/etc/otel-targetallocator/client/tls.key",
+				},
+				"authorization": map[interface{}]interface{}{
+					"type":             "Bearer",
+					"credentials_file": "/etc/otel-targetallocator/auth/token",
+				},
+			},
+		}, prometheus["target_allocator"])
+	})
+
+	t.Run("should configure the http_sd_configs with TLS", func(t *testing.T) {
+		param.Instance.Spec.TargetAllocator.PrometheusCR.Enabled = false
+		param.Instance.Spec.TargetAllocator.TLS = &v1alpha1.OpenTelemetryTargetAllocatorTLS{SecretName: "ta-cert"}
+		defer func() {
+			param.Instance.Spec.TargetAllocator.TLS = nil
+		}()
+		actualConfig, err := ReplaceConfig(param)
+		assert.NoError(t, err)
+
+		// prepare
+		var cfg Config
+		promCfgMap, err := ta.ConfigToPromConfig(actualConfig)
+		assert.NoError(t, err)
+		promCfg, err := yaml.Marshal(map[string]interface{}{
+			"config": promCfgMap,
+		})
+		assert.NoError(t, err)
+		err = yaml.UnmarshalStrict(promCfg, &cfg)
+		assert.NoError(t, err)
+
+		// test
+		for _, scrapeConfig := range cfg.PromConfig.ScrapeConfigs {
+			sdConfig := scrapeConfig.ServiceDiscoveryConfigs[0].(*http.SDConfig)
+			assert.Equal(t, "https://test-targetallocator:443/jobs/"+scrapeConfig.JobName+"/targets?collector_id=$POD_NAME", sdConfig.URL)
+			assert.Equal(t, "/etc/otel-targetallocator/tls/ca.crt", sdConfig.HTTPClientConfig.TLSConfig.CAFile)
+			assert.Empty(t, sdConfig.HTTPClientConfig.TLSConfig.CertFile)
+			assert.Nil(t, sdConfig.HTTPClientConfig.Authorization)
+		}
+	})
+}
+
+func TestPrometheusParserMultipleReceivers(t *testing.T) {
+	param, err := newParams("test/test-img", "../testdata/http_sd_config_multiple_receivers_test.yaml")
+	assert.NoError(t, err)
+
+	t.Run("should update every prometheus receiver with http_sd_config", func(t *testing.T) {
+		actualConfig, err := ReplaceConfig(param)
+		assert.NoError(t, err)
+
+		// prepare
+		promCfgMaps, err := ta.ConfigToPromConfigs(actualConfig)
+		assert.NoError(t, err)
+
+		// test
+		expectedJobs := map[string]string{
+			"prometheus/infra": "kubelet",
+			"prometheus/apps":  "service-x",
+		}
+		assert.Len(t, promCfgMaps, len(expectedJobs))
+		for name, promCfgMap := range promCfgMaps {
+			var cfg Config
+			promCfg, err := yaml.Marshal(map[string]interface{}{
+				"config": promCfgMap,
+			})
+			assert.NoError(t, err)
+			err = yaml.UnmarshalStrict(promCfg, &cfg)
+			assert.NoError(t, err)
+
+			if assert.Len(t, cfg.PromConfig.ScrapeConfigs, 1, name) {
+				scrapeConfig := cfg.PromConfig.ScrapeConfigs[0]
+				assert.Equal(t, expectedJobs[name], scrapeConfig.JobName)
+				assert.Len(t, scrapeConfig.ServiceDiscoveryConfigs, 1)
+				assert.Equal(t, "http://test-targetallocator:80/jobs/"+scrapeConfig.JobName+"/targets?collector_id=$POD_NAME", scrapeConfig.ServiceDiscoveryConfigs[0].(*http.SDConfig).URL)
+			}
+		}
+	})
+
+	t.Run("should keep the http_sd_configs of several prometheus receivers when the Prometheus CRs are enabled", func(t *testing.T) {
+		// rejected by the webhook, but the collectors still have to be configured if the webhook is disabled
+		param.Instance.Spec.TargetAllocator.PrometheusCR.Enabled = true
+		actualConfig, err := ReplaceConfig(param)
+		assert.NoError(t, err)
+
+		promCfgMaps, err := ta.ConfigToPromConfigs(actualConfig)
+		assert.NoError(t, err)
+		assert.Len(t, promCfgMaps, 2)
+		for name, promCfgMap := range promCfgMaps {
+			assert.NotContains(t, promCfgMap, "target_allocator", name)
+			assert.Contains(t, promCfgMap, "scrape_configs", name)
+		}
+	})
 }
@@ -0,0 +1,156 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+)
+
+// ConfigHashAnnotation is the annotation of the pod templates holding the hash of the configuration the pods run
+// with. Changing it rolls the pods out, so that they pick up the new configuration.
+const ConfigHashAnnotation = "opentelemetry-operator-config/sha256"
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// collectorConfigHash returns the hash of the collector's ConfigMap, of the TargetAllocator's one when it's enabled, as
+// the collectors' targets depend on it, and of the Secrets and ConfigMaps its environment variables reference.
+func collectorConfigHash(ctx context.Context, params Params) (string, error) {
+	h := sha256.New()
+	writeData(h, "configmap", desiredConfigMap(ctx, params).Data)
+	if params.Instance.Spec.TargetAllocator.Enabled {
+		taConfigMap, err := desiredTAConfigMap(params)
+		if err != nil {
+			return "", err
+		}
+		writeData(h, "targetallocator/configmap", taConfigMap.Data)
+	}
+
+	secrets, configMaps := ReferencedConfigSources(params.Instance)
+	for _, name := range secrets {
+		secret := &corev1.Secret{}
+		err := params.Client.Get(ctx, client.ObjectKey{Namespace: params.Instance.Namespace, Name: name}, secret)
+		if k8serrors.IsNotFound(err) {
+			// the pods can't start until it's created, unless it's optional: either way, creating it changes the hash
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to get the secret %s: %w", name, err)
+		}
+		data := make(map[string]string, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		writeData(h, "secret/"+name, data)
+	}
+	for _, name := range configMaps {
+		cm := &corev1.ConfigMap{}
+		err := params.Client.Get(ctx, client.ObjectKey{Namespace: params.Instance.Namespace, Name: name}, cm)
+		if k8serrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to get the config map %s: %w", name, err)
+		}
+		data := make(map[string]string, len(cm.Data)+len(cm.BinaryData))
+		for k, v := range cm.Data {
+			data[k] = v
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = string(v)
+		}
+		writeData(h, "configmap/"+name, data)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// targetAllocatorConfigHash returns the hash of the TargetAllocator's ConfigMap.
+func targetAllocatorConfigHash(params Params) (string, error) {
+	cm, err := desiredTAConfigMap(params)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	writeData(h, "configmap", cm.Data)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// writeData writes the entries of the data to the hash in a stable order.
+func writeData(h hash.Hash, source string, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(h, "%s\x00", source)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\x00%d\x00%s\x00", k, len(data[k]), data[k])
+	}
+}
+
+// setConfigHash sets the config hash annotation of the pod template.
+func setConfigHash(template *corev1.PodTemplateSpec, configHash string) {
+	// new map every time, as the builders may share the instance's annotations
+	annotations := make(map[string]string, len(template.Annotations)+1)
+	for k, v := range template.Annotations {
+		annotations[k] = v
+	}
+	annotations[ConfigHashAnnotation] = configHash
+	template.Annotations = annotations
+}
+
+// ReferencedConfigSources returns the names of the Secrets and of the ConfigMaps the environment variables of the
+// collector reference, sorted.
+func ReferencedConfigSources(instance v1alpha1.OpenTelemetryCollector) (secrets []string, configMaps []string) {
+	secretNames := map[string]struct{}{}
+	configMapNames := map[string]struct{}{}
+	for _, env := range instance.Spec.Env {
+		if env.ValueFrom == nil {
+			continue
+		}
+		if env.ValueFrom.SecretKeyRef != nil {
+			secretNames[env.ValueFrom.SecretKeyRef.Name] = struct{}{}
+		}
+		if env.ValueFrom.ConfigMapKeyRef != nil {
+			configMapNames[env.ValueFrom.ConfigMapKeyRef.Name] = struct{}{}
+		}
+	}
+	for _, envFrom := range instance.Spec.EnvFrom {
+		if envFrom.SecretRef != nil {
+			secretNames[envFrom.SecretRef.Name] = struct{}{}
+		}
+		if envFrom.ConfigMapRef != nil {
+			configMapNames[envFrom.ConfigMapRef.Name] = struct{}{}
+		}
+	}
+	return sortedNames(secretNames), sortedNames(configMapNames)
+}
+
+func sortedNames(names map[string]struct{}) []string {
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReferencedConfigSources(t *testing.T) {
+	instance := params().Instance
+	instance.Spec.Env = []v1.EnvVar{
+		{Name: "PLAIN", Value: "value"},
+		{Name: "TOKEN", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "token"}, Key: "token"}}},
+		{Name: "LEVEL", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "settings"}, Key: "level"}}},
+	}
+	instance.Spec.EnvFrom = []v1.EnvFromSource{
+		{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "credentials"}}},
+		{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "token"}}},
+		{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "defaults"}}},
+	}
+
+	secrets, configMaps := ReferencedConfigSources(instance)
+	assert.Equal(t, []string{"credentials", "token"}, secrets)
+	assert.Equal(t, []string{"defaults", "settings"}, configMaps)
+}
+
+func TestSetConfigHash(t *testing.T) {
+	shared := map[string]string{"team": "observability"}
+	template := v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: shared}}
+
+	setConfigHash(&template, "abc")
+	assert.Equal(t, map[string]string{"team": "observability", ConfigHashAnnotation: "abc"}, template.Annotations)
+	// the map the template was built with is left untouched
+	assert.Equal(t, map[string]string{"team": "observability"}, shared)
+}
+
+func TestTargetAllocatorConfigHash(t *testing.T) {
+	param, err := newParams("test/test-img", "")
+	require.NoError(t, err)
+
+	first, err := targetAllocatorConfigHash(param)
+	require.NoError(t, err)
+	second, err := targetAllocatorConfigHash(param)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	param.Instance.Spec.TargetAllocator.PrometheusCR.Enabled = true
+	param.Instance.Spec.TargetAllocator.PrometheusCR.ServiceMonitorSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	changed, err := targetAllocatorConfigHash(param)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+}
+
+func TestCollectorConfigHashIncludesTargetAllocatorConfig(t *testing.T) {
+	param, err := newParams("test/test-img", "")
+	require.NoError(t, err)
+
+	first, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+
+	// a change of the TargetAllocator's config rolls the collectors out too
+	param.Instance.Spec.TargetAllocator.PrometheusCR.Enabled = true
+	param.Instance.Spec.TargetAllocator.PrometheusCR.ServiceMonitorSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	changed, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, changed)
+
+	param.Instance.Spec.TargetAllocator.Enabled = false
+	disabled, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, disabled)
+}
+
+func TestCollectorConfigHash(t *testing.T) {
+	param := params()
+	param.Instance.Name = "confighash"
+	param.Instance.Spec.EnvFrom = []v1.EnvFromSource{
+		{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "confighash-credentials"}}},
+	}
+
+	missing, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "confighash-credentials", Namespace: "default"},
+		Data:       map[string][]byte{"API_KEY": []byte("first")},
+	}
+	createObjectIfNotExists(t, secret.Name, secret)
+	created, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, missing, created)
+
+	secret.Data["API_KEY"] = []byte("second")
+	require.NoError(t, k8sClient.Update(context.Background(), secret))
+	updated, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, created, updated)
+
+	param.Instance.Spec.EnvFrom = append(param.Instance.Spec.EnvFrom, v1.EnvFromSource{
+		ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "confighash-settings"}},
+	})
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "confighash-settings", Namespace: "default"},
+		Data:       map[string]string{"LOG_LEVEL": "info"},
+	}
+	createObjectIfNotExists(t, cm.Name, cm)
+	withConfigMap, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, updated, withConfigMap)
+
+	cm.Data["LOG_LEVEL"] = "debug"
+	require.NoError(t, k8sClient.Update(context.Background(), cm))
+	updated, err = collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, withConfigMap, updated)
+
+	param.Instance.Spec.Config = "receivers: {}"
+	reconfigured, err := collectorConfigHash(context.Background(), param)
+	require.NoError(t, err)
+	assert.NotEqual(t, updated, reconfigured)
+}
@@ -26,7 +26,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
@@ -51,12 +50,16 @@ func ConfigMaps(ctx context.Context, params Params) error {
 	}
 
 	// first, handle the create/update parts
-	if err := expectedConfigMaps(ctx, params, desired, true); err != nil {
+	if err := expectedConfigMaps(ctx, params, desired); err != nil {
 		return fmt.Errorf("failed to reconcile the expected configmaps: %w", err)
 	}
 
 	// then, delete the extra objects
-	if err := deleteConfigMaps(ctx, params, desired); err != nil {
+	objects := make([]client.Object, 0, len(desired))
+	for i := range desired {
+		objects = append(objects, &desired[i])
+	}
+	if err := deleteObjects(ctx, params, &corev1.ConfigMapList{}, objects); err != nil {
 		return fmt.Errorf("failed to reconcile the configmaps to be deleted: %w", err)
 	}
 
@@ -114,6 +117,20 @@ func desiredTAConfigMap(params Params) (corev1.ConfigMap, error) {
 		"app.kubernetes.io/component":  "opentelemetry-collector",
 	}
 	taConfig["config"] = promConfig
+
+	prometheusCR := params.Instance.Spec.TargetAllocator.PrometheusCR
+	if prometheusCR.ServiceMonitorSelector != nil {
+		taConfig["service_monitor_selector"] = taLabelSelector(prometheusCR.ServiceMonitorSelector)
+	}
+	if prometheusCR.PodMonitorSelector != nil {
+		taConfig["pod_monitor_selector"] = taLabelSelector(prometheusCR.PodMonitorSelector)
+	}
+	if prometheusCR.ProbeSelector != nil {
+		taConfig["probe_selector"] = taLabelSelector(prometheusCR.ProbeSelector)
+	}
+	if prometheusCR.NamespaceSelector != nil {
+		taConfig["namespace_selector"] = taLabelSelector(prometheusCR.NamespaceSelector)
+	}
 	taConfigYAML, err := yaml.Marshal(taConfig)
 	if err != nil {
 		return corev1.ConfigMap{}, err
@@ -132,100 +149,36 @@ func desiredTAConfigMap(params Params) (corev1.ConfigMap, error) {
 	}, nil
 }
 
-func expectedConfigMaps(ctx context.Context, params Params, expected []corev1.ConfigMap, retry bool) error {
+// taLabelSelector converts a label selector to the format of the TargetAllocator's config file.
+func taLabelSelector(selector *metav1.LabelSelector) map[string]interface{} {
+	converted := make(map[string]interface{})
+	if len(selector.MatchLabels) > 0 {
+		converted["matchLabels"] = selector.MatchLabels
+	}
+	if len(selector.MatchExpressions) > 0 {
+		converted["matchExpressions"] = selector.MatchExpressions
+	}
+	return converted
+}
+
+// expectedConfigMaps applies the config maps, and records an event when the content of an existing one changes.
+func expectedConfigMaps(ctx context.Context, params Params, expected []corev1.ConfigMap) error {
 	for _, obj := range expected {
 		desired := obj
 
-		if err := controllerutil.SetControllerReference(&params.Instance, &desired, params.Scheme); err != nil {
-			return fmt.Errorf("failed to set controller reference: %w", err)
-		}
-
 		existing := &corev1.ConfigMap{}
 		nns := types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}
 		err := params.Client.Get(ctx, nns, existing)
-		if err != nil && errors.IsNotFound(err) {
-			if err := params.Client.Create(ctx, &desired); err != nil {
-				if errors.IsAlreadyExists(err) && retry {
-					// let's try again? we probably had multiple updates at one, and now it exists already
-					if err := expectedConfigMaps(ctx, params, expected, false); err != nil {
-						// somethin else happened now...
-						return err
-					}
-
-					// we succeeded in the retry, exit this attempt
-					return nil
-				}
-				return fmt.Errorf("failed to create: %w", err)
-			}
-			params.Log.V(2).Info("created", "configmap.name", desired.Name, "configmap.namespace", desired.Namespace)
-			continue
-		} else if err != nil {
+		found := err == nil
+		if err != nil && !errors.IsNotFound(err) {
 			return fmt.Errorf("failed to get: %w", err)
 		}
 
-		// it exists already, merge the two if the end result isn't identical to the existing one
-		updated := existing.DeepCopy()
-		if updated.Annotations == nil {
-			updated.Annotations = map[string]string{}
+		if err := applyObject(ctx, params, &desired); err != nil {
+			return err
 		}
-		if updated.Labels == nil {
-			updated.Labels = map[string]string{}
-		}
-
-		updated.Data = desired.Data
-		updated.BinaryData = desired.BinaryData
-		updated.ObjectMeta.OwnerReferences = desired.ObjectMeta.OwnerReferences
-
-		for k, v := range desired.ObjectMeta.Annotations {
-			updated.ObjectMeta.Annotations[k] = v
-		}
-		for k, v := range desired.ObjectMeta.Labels {
-			updated.ObjectMeta.Labels[k] = v
-		}
-
-		patch := client.MergeFrom(existing)
-
-		if err := params.Client.Patch(ctx, updated, patch); err != nil {
-			return fmt.Errorf("failed to apply changes: %w", err)
-		}
-		if configMapChanged(&desired, existing) {
-			params.Recorder.Event(updated, "Normal", "ConfigUpdate ", fmt.Sprintf("OpenTelemetry Config changed - %s/%s", desired.Namespace, desired.Name))
-		}
-
-		params.Log.V(2).Info("applied", "configmap.name", desired.Name, "configmap.namespace", desired.Namespace)
-	}
-
-	return nil
-}
-
-func deleteConfigMaps(ctx context.Context, params Params, expected []corev1.ConfigMap) error {
-	opts := []client.ListOption{
-		client.InNamespace(params.Instance.Namespace),
-		client.MatchingLabels(map[string]string{
-			"app.kubernetes.io/instance":   fmt.Sprintf("%s.%s", params.Instance.Namespace, params.Instance.Name),
-			"app.kubernetes.io/managed-by": "opentelemetry-operator",
-		}),
-	}
-	list := &corev1.ConfigMapList{}
-	if err := params.Client.List(ctx, list, opts...); err != nil {
-		return fmt.Errorf("failed to list: %w", err)
-	}
-
-	for i := range list.Items {
-		existing := list.Items[i]
-		del := true
-		for _, keep := range expected {
-			if keep.Name == existing.Name && keep.Namespace == existing.Namespace {
-				del = false
-				break
-			}
-		}
-
-		if del {
-			if err := params.Client.Delete(ctx, &existing); err != nil {
-				return fmt.Errorf("failed to delete: %w", err)
-			}
-			params.Log.V(2).Info("deleted", "configmap.name", existing.Name, "configmap.namespace", existing.Namespace)
+		if found && configMapChanged(&desired, existing) {
+			params.Recorder.Event(&desired, "Normal", "ConfigUpdate ", fmt.Sprintf("OpenTelemetry Config changed - %s/%s", desired.Namespace, desired.Name))
 		}
 	}
 
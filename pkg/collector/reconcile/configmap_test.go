@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
@@ -157,13 +158,51 @@ label_selector:
 
 	})
 
+	t.Run("should add the monitor selectors to the target allocator config map", func(t *testing.T) {
+		param := params()
+		param.Instance.Spec.TargetAllocator.PrometheusCR = v1alpha1.OpenTelemetryTargetAllocatorPrometheusCR{
+			Enabled: true,
+			ServiceMonitorSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "a"},
+			},
+			// selects all the PodMonitors, unlike an unset selector
+			PodMonitorSelector: &metav1.LabelSelector{},
+			ProbeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"prober": "blackbox"},
+			},
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tenant", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+				},
+			},
+		}
+
+		actual, err := desiredTAConfigMap(param)
+		assert.NoError(t, err)
+
+		taConfig := make(map[string]interface{})
+		assert.NoError(t, yaml.Unmarshal([]byte(actual.Data["targetallocator.yaml"]), &taConfig))
+		assert.Equal(t, map[interface{}]interface{}{
+			"matchLabels": map[interface{}]interface{}{"team": "a"},
+		}, taConfig["service_monitor_selector"])
+		assert.Equal(t, map[interface{}]interface{}{
+			"matchExpressions": []interface{}{
+				map[interface{}]interface{}{"key": "tenant", "operator": "In", "values": []interface{}{"a", "b"}},
+			},
+		}, taConfig["namespace_selector"])
+		assert.Equal(t, map[interface{}]interface{}{
+			"matchLabels": map[interface{}]interface{}{"prober": "blackbox"},
+		}, taConfig["probe_selector"])
+		assert.Equal(t, map[interface{}]interface{}{}, taConfig["pod_monitor_selector"])
+	})
+
 }
 
 func TestExpectedConfigMap(t *testing.T) {
 	t.Run("should create collector and target allocator config maps", func(t *testing.T) {
 		configMap, err := desiredTAConfigMap(params())
 		assert.NoError(t, err)
-		err = expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{desiredConfigMap(context.Background(), params()), configMap}, true)
+		err = expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{desiredConfigMap(context.Background(), params()), configMap})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.ConfigMap{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -200,7 +239,7 @@ func TestExpectedConfigMap(t *testing.T) {
 		cm := desiredConfigMap(context.Background(), param)
 		createObjectIfNotExists(t, "test-collector", &cm)
 
-		err := expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{desiredConfigMap(context.Background(), params())}, true)
+		err := expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{desiredConfigMap(context.Background(), params())})
 		assert.NoError(t, err)
 
 		actual := v1.ConfigMap{}
@@ -252,7 +291,7 @@ func TestExpectedConfigMap(t *testing.T) {
 
 		configMap, err := desiredTAConfigMap(params())
 		assert.NoError(t, err)
-		err = expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{configMap}, true)
+		err = expectedConfigMaps(context.Background(), params(), []v1.ConfigMap{configMap})
 		assert.NoError(t, err)
 
 		actual := v1.ConfigMap{}
@@ -294,7 +333,8 @@ func TestExpectedConfigMap(t *testing.T) {
 		exists, _ := populateObjectIfExists(t, &v1.ConfigMap{}, types.NamespacedName{Namespace: "default", Name: "test-delete-collector"})
 		assert.True(t, exists)
 
-		err := deleteConfigMaps(context.Background(), params(), []v1.ConfigMap{desiredConfigMap(context.Background(), params())})
+		desired := desiredConfigMap(context.Background(), params())
+		err := deleteObjects(context.Background(), params(), &v1.ConfigMapList{}, []client.Object{&desired})
 		assert.NoError(t, err)
 
 		exists, _ = populateObjectIfExists(t, &v1.ConfigMap{}, types.NamespacedName{Namespace: "default", Name: "test-delete-collector"})
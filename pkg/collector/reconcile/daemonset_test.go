@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
 )
@@ -32,7 +33,7 @@ func TestExpectedDaemonsets(t *testing.T) {
 	expectedDs := collector.DaemonSet(param.Config, logger, param.Instance)
 
 	t.Run("should create Daemonset", func(t *testing.T) {
-		err := expectedDaemonSets(context.Background(), param, []v1.DaemonSet{expectedDs})
+		err := expectedObjects(context.Background(), param, []client.Object{&expectedDs})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.DaemonSet{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -43,7 +44,7 @@ func TestExpectedDaemonsets(t *testing.T) {
 	})
 	t.Run("should update Daemonset", func(t *testing.T) {
 		createObjectIfNotExists(t, "test-collector", &expectedDs)
-		err := expectedDaemonSets(context.Background(), param, []v1.DaemonSet{expectedDs})
+		err := expectedObjects(context.Background(), param, []client.Object{&expectedDs})
 		assert.NoError(t, err)
 
 		actual := v1.DaemonSet{}
@@ -83,7 +84,7 @@ func TestExpectedDaemonsets(t *testing.T) {
 
 		createObjectIfNotExists(t, "dummy", &ds)
 
-		err := deleteDaemonSets(context.Background(), param, []v1.DaemonSet{expectedDs})
+		err := deleteObjects(context.Background(), param, &v1.DaemonSetList{}, []client.Object{&expectedDs})
 		assert.NoError(t, err)
 
 		actual := v1.DaemonSet{}
@@ -121,7 +122,7 @@ func TestExpectedDaemonsets(t *testing.T) {
 
 		createObjectIfNotExists(t, "dummy", &ds)
 
-		err := deleteDaemonSets(context.Background(), param, []v1.DaemonSet{expectedDs})
+		err := deleteObjects(context.Background(), param, &v1.DaemonSetList{}, []client.Object{&expectedDs})
 		assert.NoError(t, err)
 
 		actual := v1.DaemonSet{}
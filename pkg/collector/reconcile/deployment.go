@@ -22,7 +22,6 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
@@ -33,121 +32,58 @@ import (
 
 // Deployments reconciles the deployment(s) required for the instance in the current context.
 func Deployments(ctx context.Context, params Params) error {
-	desired := []appsv1.Deployment{}
+	desired := []client.Object{}
 	if params.Instance.Spec.Mode == "deployment" {
-		desired = append(desired, collector.Deployment(params.Config, params.Log, params.Instance))
-	}
-
-	if params.Instance.Spec.TargetAllocator.Enabled {
-		desired = append(desired, targetallocator.Deployment(params.Config, params.Log, params.Instance))
-	}
-
-	// first, handle the create/update parts
-	if err := expectedDeployments(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the expected deployments: %w", err)
-	}
-
-	// then, delete the extra objects
-	if err := deleteDeployments(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the deployments to be deleted: %w", err)
-	}
-
-	return nil
-}
-
-func expectedDeployments(ctx context.Context, params Params, expected []appsv1.Deployment) error {
-	for _, obj := range expected {
-		desired := obj
-
-		if err := controllerutil.SetControllerReference(&params.Instance, &desired, params.Scheme); err != nil {
-			return fmt.Errorf("failed to set controller reference: %w", err)
+		configHash, err := collectorConfigHash(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to compute the config hash: %w", err)
 		}
+		d := collector.Deployment(params.Config, params.Log, params.Instance)
+		setConfigHash(&d.Spec.Template, configHash)
 
-		existing := &appsv1.Deployment{}
-		nns := types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}
-		err := params.Client.Get(ctx, nns, existing)
-		if err != nil && k8serrors.IsNotFound(err) {
-			if err := params.Client.Create(ctx, &desired); err != nil {
-				return fmt.Errorf("failed to create: %w", err)
+		// the autoscaler scales the collector between the replicas and the max replicas
+		if params.Instance.Spec.MaxReplicas != nil {
+			replicas, err := replicasWithHPA(ctx, params, d)
+			if err != nil {
+				return err
 			}
-			params.Log.V(2).Info("created", "deployment.name", desired.Name, "deployment.namespace", desired.Namespace)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to get: %w", err)
-		}
-
-		// it exists already, merge the two if the end result isn't identical to the existing one
-		updated := existing.DeepCopy()
-		if updated.Annotations == nil {
-			updated.Annotations = map[string]string{}
-		}
-		if updated.Labels == nil {
-			updated.Labels = map[string]string{}
-		}
-
-		if desired.Labels["app.kubernetes.io/component"] == "opentelemetry-targetallocator" {
-			updated.Spec.Template.Spec.Containers[0].Image = desired.Spec.Template.Spec.Containers[0].Image
-		} else {
-			updated.Spec = desired.Spec
-		}
-		updated.ObjectMeta.OwnerReferences = desired.ObjectMeta.OwnerReferences
-
-		for k, v := range desired.ObjectMeta.Annotations {
-			updated.ObjectMeta.Annotations[k] = v
-		}
-		for k, v := range desired.ObjectMeta.Labels {
-			updated.ObjectMeta.Labels[k] = v
+			d.Spec.Replicas = &replicas
 		}
+		desired = append(desired, &d)
+	}
 
-		if params.Instance.Spec.MaxReplicas != nil {
-			currentReplicas := currentReplicasWithHPA(params.Instance.Spec, existing.Status.Replicas)
-			updated.Spec.Replicas = &currentReplicas
+	if params.Instance.Spec.TargetAllocator.Enabled {
+		configHash, err := targetAllocatorConfigHash(params)
+		if err != nil {
+			return fmt.Errorf("failed to compute the config hash of the target allocator: %w", err)
 		}
+		d := targetallocator.Deployment(params.Config, params.Log, params.Instance)
+		setConfigHash(&d.Spec.Template, configHash)
 
-		patch := client.MergeFrom(existing)
-
-		if err := params.Client.Patch(ctx, updated, patch); err != nil {
-			return fmt.Errorf("failed to apply changes: %w", err)
+		// the replicas are only applied when they are configured, so that they can be changed by hand otherwise
+		if params.Instance.Spec.TargetAllocator.Replicas == nil {
+			d.Spec.Replicas = nil
 		}
+		desired = append(desired, &d)
+	}
 
-		params.Log.V(2).Info("applied", "deployment.name", desired.Name, "deployment.namespace", desired.Namespace)
+	if err := reconcileObjects(ctx, params, &appsv1.DeploymentList{}, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the deployments: %w", err)
 	}
 
 	return nil
 }
 
-func deleteDeployments(ctx context.Context, params Params, expected []appsv1.Deployment) error {
-	opts := []client.ListOption{
-		client.InNamespace(params.Instance.Namespace),
-		client.MatchingLabels(map[string]string{
-			"app.kubernetes.io/instance":   fmt.Sprintf("%s.%s", params.Instance.Namespace, params.Instance.Name),
-			"app.kubernetes.io/managed-by": "opentelemetry-operator",
-		}),
-	}
-	list := &appsv1.DeploymentList{}
-	if err := params.Client.List(ctx, list, opts...); err != nil {
-		return fmt.Errorf("failed to list: %w", err)
-	}
-
-	for i := range list.Items {
-		existing := list.Items[i]
-		del := true
-		for _, keep := range expected {
-			if keep.Name == existing.Name && keep.Namespace == existing.Namespace {
-				del = false
-				break
-			}
-		}
-
-		if del {
-			if err := params.Client.Delete(ctx, &existing); err != nil {
-				return fmt.Errorf("failed to delete: %w", err)
-			}
-			params.Log.V(2).Info("deleted", "deployment.name", existing.Name, "deployment.namespace", existing.Namespace)
-		}
+// replicasWithHPA returns the replicas of the deployment, as scaled by the autoscaler within its bounds.
+func replicasWithHPA(ctx context.Context, params Params, desired appsv1.Deployment) (int32, error) {
+	existing := &appsv1.Deployment{}
+	err := params.Client.Get(ctx, types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}, existing)
+	if k8serrors.IsNotFound(err) {
+		return *params.Instance.Spec.Replicas, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get the deployment %s: %w", desired.Name, err)
 	}
-
-	return nil
+	return currentReplicasWithHPA(params.Instance.Spec, existing.Status.Replicas), nil
 }
 
 // currentReplicasWithHPA calculates deployment replicas if HPA is enabled.
@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
@@ -35,7 +36,7 @@ func TestExpectedDeployments(t *testing.T) {
 	expectedTADeploy := targetallocator.Deployment(param.Config, logger, param.Instance)
 
 	t.Run("should create collector deployment", func(t *testing.T) {
-		err := expectedDeployments(context.Background(), param, []v1.Deployment{expectedDeploy})
+		err := expectedObjects(context.Background(), param, []client.Object{&expectedDeploy})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.Deployment{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -46,7 +47,7 @@ func TestExpectedDeployments(t *testing.T) {
 	})
 
 	t.Run("should create target allocator deployment", func(t *testing.T) {
-		err := expectedDeployments(context.Background(), param, []v1.Deployment{expectedTADeploy})
+		err := expectedObjects(context.Background(), param, []client.Object{&expectedTADeploy})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.Deployment{}, types.NamespacedName{Namespace: "default", Name: "test-targetallocator"})
@@ -104,7 +105,7 @@ func TestExpectedDeployments(t *testing.T) {
 
 	t.Run("should update deployment", func(t *testing.T) {
 		createObjectIfNotExists(t, "test-collector", &expectedDeploy)
-		err := expectedDeployments(context.Background(), param, []v1.Deployment{expectedDeploy})
+		err := expectedObjects(context.Background(), param, []client.Object{&expectedDeploy})
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
@@ -116,17 +117,22 @@ func TestExpectedDeployments(t *testing.T) {
 		assert.Equal(t, int32(2), *actual.Spec.Replicas)
 	})
 
-	t.Run("should not update target allocator deployment when the container image is not updated", func(t *testing.T) {
+	t.Run("should not change the replicas of the target allocator deployment when they are not configured", func(t *testing.T) {
 		ctx := context.Background()
 		createObjectIfNotExists(t, "test-targetallocator", &expectedTADeploy)
 		orgUID := expectedTADeploy.OwnerReferences[0].UID
 
-		updatedParam, err := newParams("test/test-img", "")
+		// scaled by hand
+		scaled := v1.Deployment{}
+		_, err := populateObjectIfExists(t, &scaled, types.NamespacedName{Namespace: "default", Name: "test-targetallocator"})
 		assert.NoError(t, err)
-		updatedDeploy := targetallocator.Deployment(updatedParam.Config, logger, param.Instance)
-		*updatedDeploy.Spec.Replicas = int32(3)
+		three := int32(3)
+		scaled.Spec.Replicas = &three
+		assert.NoError(t, k8sClient.Update(ctx, &scaled))
 
-		err = expectedDeployments(ctx, param, []v1.Deployment{updatedDeploy})
+		taParam, err := newParams("", "")
+		assert.NoError(t, err)
+		err = Deployments(ctx, taParam)
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
@@ -136,7 +142,8 @@ func TestExpectedDeployments(t *testing.T) {
 		assert.True(t, exists)
 		assert.Equal(t, orgUID, actual.OwnerReferences[0].UID)
 		assert.Equal(t, expectedTADeploy.Spec.Template.Spec.Containers[0].Image, actual.Spec.Template.Spec.Containers[0].Image)
-		assert.Equal(t, int32(1), *actual.Spec.Replicas)
+		assert.Equal(t, int32(3), *actual.Spec.Replicas)
+		assert.NotEmpty(t, actual.Spec.Template.Annotations[ConfigHashAnnotation])
 	})
 
 	t.Run("should update target allocator deployment when the container image is updated", func(t *testing.T) {
@@ -148,7 +155,7 @@ func TestExpectedDeployments(t *testing.T) {
 		assert.NoError(t, err)
 		updatedDeploy := targetallocator.Deployment(updatedParam.Config, logger, updatedParam.Instance)
 
-		err = expectedDeployments(ctx, param, []v1.Deployment{updatedDeploy})
+		err = expectedObjects(ctx, param, []client.Object{&updatedDeploy})
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
@@ -161,6 +168,29 @@ func TestExpectedDeployments(t *testing.T) {
 		assert.Equal(t, int32(1), *actual.Spec.Replicas)
 	})
 
+	t.Run("should update target allocator deployment when TLS and the bearer token are enabled", func(t *testing.T) {
+		ctx := context.Background()
+		createObjectIfNotExists(t, "test-targetallocator", &expectedTADeploy)
+
+		securedParam, err := newParams("test/test-img", "")
+		assert.NoError(t, err)
+		securedParam.Instance.Spec.TargetAllocator.TLS = &v1alpha1.OpenTelemetryTargetAllocatorTLS{SecretName: "ta-cert"}
+		securedParam.Instance.Spec.TargetAllocator.BearerTokenSecret = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "ta-token"},
+			Key:                  "token",
+		}
+		err = Deployments(ctx, securedParam)
+		assert.NoError(t, err)
+
+		actual := v1.Deployment{}
+		exists, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "test-targetallocator"})
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Contains(t, actual.Spec.Template.Spec.Containers[0].Args, "--tls-cert-file=/tls/tls.crt")
+		assert.Contains(t, actual.Spec.Template.Spec.Containers[0].Args, "--bearer-token-file=/auth/token")
+		assert.Len(t, actual.Spec.Template.Spec.Volumes, 3)
+	})
+
 	t.Run("should delete deployment", func(t *testing.T) {
 		labels := map[string]string{
 			"app.kubernetes.io/instance":   "default.test",
@@ -188,7 +218,7 @@ func TestExpectedDeployments(t *testing.T) {
 		}
 		createObjectIfNotExists(t, "dummy", &deploy)
 
-		err := deleteDeployments(context.Background(), param, []v1.Deployment{expectedDeploy})
+		err := deleteObjects(context.Background(), param, &v1.DeploymentList{}, []client.Object{&expectedDeploy})
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
@@ -224,7 +254,7 @@ func TestExpectedDeployments(t *testing.T) {
 		}
 		createObjectIfNotExists(t, "dummy", &deploy)
 
-		err := deleteDeployments(context.Background(), param, []v1.Deployment{expectedDeploy})
+		err := deleteObjects(context.Background(), param, &v1.DeploymentList{}, []client.Object{&expectedDeploy})
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
@@ -39,7 +40,7 @@ func TestExpectedHPA(t *testing.T) {
 	expectedHPA := collector.HorizontalPodAutoscaler(params.Config, logger, params.Instance)
 
 	t.Run("should create HPA", func(t *testing.T) {
-		err := expectedHorizontalPodAutoscalers(context.Background(), params, []autoscalingv1.HorizontalPodAutoscaler{expectedHPA})
+		err := expectedObjects(context.Background(), params, []client.Object{&expectedHPA})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &autoscalingv1.HorizontalPodAutoscaler{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -55,8 +56,9 @@ func TestExpectedHPA(t *testing.T) {
 		updateParms.Instance.Spec.MaxReplicas = &maxReplicas
 		updatedHPA := collector.HorizontalPodAutoscaler(updateParms.Config, logger, updateParms.Instance)
 
-		createObjectIfNotExists(t, "test-collector", &updatedHPA)
-		err := expectedHorizontalPodAutoscalers(context.Background(), updateParms, []autoscalingv1.HorizontalPodAutoscaler{updatedHPA})
+		existing := expectedHPA.DeepCopy()
+		createObjectIfNotExists(t, "test-collector", existing)
+		err := expectedObjects(context.Background(), updateParms, []client.Object{&updatedHPA})
 		assert.NoError(t, err)
 
 		actual := autoscalingv1.HorizontalPodAutoscaler{}
@@ -69,7 +71,7 @@ func TestExpectedHPA(t *testing.T) {
 	})
 
 	t.Run("should delete HPA", func(t *testing.T) {
-		err := deleteHorizontalPodAutoscalers(context.Background(), params, []autoscalingv1.HorizontalPodAutoscaler{expectedHPA})
+		err := deleteObjects(context.Background(), params, &autoscalingv1.HorizontalPodAutoscalerList{}, []client.Object{&expectedHPA})
 		assert.NoError(t, err)
 
 		actual := v1.Deployment{}
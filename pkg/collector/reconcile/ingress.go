@@ -0,0 +1,240 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/platform"
+)
+
+// RouteGVK is the kind of the OpenShift routes. The OpenShift API isn't a dependency of the operator: the routes are
+// handled as unstructured objects.
+var RouteGVK = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+
+// grpcBackendProtocolAnnotation tells the NGINX ingress controller to talk gRPC to the backends of an ingress.
+const grpcBackendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes;routes/custom-host,verbs=get;list;watch;create;update;patch;delete
+
+// Ingresses reconciles the ingresses exposing the receivers of the instance, or the routes on OpenShift. The ones
+// which aren't chosen are deleted, in case the platform was detected differently when they were created.
+func Ingresses(ctx context.Context, params Params) error {
+	desiredIngresses := []client.Object{}
+	desiredRoutes := []client.Object{}
+	if params.Instance.Spec.Ingress.Type == v1alpha1.IngressTypeIngress && params.Instance.Spec.Mode != v1alpha1.ModeSidecar {
+		if params.Config.Platform() == platform.OpenShift {
+			for _, route := range desiredRouteList(params) {
+				desiredRoutes = append(desiredRoutes, route)
+			}
+		} else {
+			for _, ingress := range desiredIngressList(params) {
+				desiredIngresses = append(desiredIngresses, ingress)
+			}
+		}
+	}
+
+	if err := reconcileObjects(ctx, params, &networkingv1.IngressList{}, desiredIngresses); err != nil {
+		return fmt.Errorf("failed to reconcile the ingresses: %w", err)
+	}
+
+	// the routes can't exist on a cluster detected as Kubernetes, as it doesn't serve them. Without detection, the ones
+	// created before are deleted if the cluster serves them
+	if params.Config.Platform() != platform.Kubernetes {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(RouteGVK.GroupVersion().WithKind(RouteGVK.Kind + "List"))
+		err := reconcileObjects(ctx, params, list, desiredRoutes)
+		var noMatch *meta.NoKindMatchError
+		if err != nil && !(params.Config.Platform() == platform.Unknown && errors.As(err, &noMatch)) {
+			return fmt.Errorf("failed to reconcile the routes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// desiredIngressList returns the ingress of the HTTP receivers and the ingress of the gRPC receivers, when they
+// have ports to expose.
+func desiredIngressList(params Params) []*networkingv1.Ingress {
+	var httpPorts, grpcPorts []corev1.ServicePort
+	for _, port := range exposedPorts(params) {
+		if isGRPC(port) {
+			grpcPorts = append(grpcPorts, port)
+		} else {
+			httpPorts = append(httpPorts, port)
+		}
+	}
+
+	ingresses := []*networkingv1.Ingress{}
+	if len(httpPorts) > 0 {
+		ingresses = append(ingresses, desiredIngress(params, naming.Ingress(params.Instance), httpPorts, nil))
+	}
+	if len(grpcPorts) > 0 {
+		extra := map[string]string{grpcBackendProtocolAnnotation: "GRPC"}
+		ingresses = append(ingresses, desiredIngress(params, naming.GRPCIngress(params.Instance), grpcPorts, extra))
+	}
+	return ingresses
+}
+
+func desiredIngress(params Params, name string, ports []corev1.ServicePort, extraAnnotations map[string]string) *networkingv1.Ingress {
+	labels := collector.Labels(params.Instance, []string{})
+	labels["app.kubernetes.io/name"] = name
+
+	// a host per port: the paths of the gRPC services can't be prefixed to tell the receivers apart
+	pathType := networkingv1.PathTypePrefix
+	rules := make([]networkingv1.IngressRule, 0, len(ports))
+	for _, port := range ports {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: portHost(params, port),
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: naming.Service(params.Instance),
+								Port: networkingv1.ServiceBackendPort{Number: port.Port},
+							},
+						},
+					}},
+				},
+			},
+		})
+	}
+
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   params.Instance.Namespace,
+			Labels:      labels,
+			Annotations: ingressAnnotations(params, extraAnnotations),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: params.Instance.Spec.Ingress.IngressClassName,
+			TLS:              params.Instance.Spec.Ingress.TLS,
+			Rules:            rules,
+		},
+	}
+}
+
+// desiredRouteList returns a route per exposed port, as a route points to a single port of a service.
+func desiredRouteList(params Params) []*unstructured.Unstructured {
+	routes := []*unstructured.Unstructured{}
+	for _, port := range exposedPorts(params) {
+		name := naming.Route(params.Instance, port.Name)
+		labels := collector.Labels(params.Instance, []string{})
+		labels["app.kubernetes.io/name"] = name
+
+		// the route points to the port of the pods, which is the port of the service unless set otherwise
+		var target interface{} = int64(port.Port)
+		if port.TargetPort.Type == intstr.String {
+			target = port.TargetPort.StrVal
+		} else if port.TargetPort.IntVal != 0 {
+			target = int64(port.TargetPort.IntVal)
+		}
+
+		spec := map[string]interface{}{
+			"host": portHost(params, port),
+			"to": map[string]interface{}{
+				"kind": "Service",
+				"name": naming.Service(params.Instance),
+			},
+			"port": map[string]interface{}{
+				"targetPort": target,
+			},
+			"wildcardPolicy": "None",
+		}
+		if len(params.Instance.Spec.Ingress.TLS) > 0 {
+			spec["tls"] = map[string]interface{}{
+				"termination":                   "edge",
+				"insecureEdgeTerminationPolicy": "Redirect",
+			}
+		}
+
+		route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		route.SetGroupVersionKind(RouteGVK)
+		route.SetName(name)
+		route.SetNamespace(params.Instance.Namespace)
+		route.SetLabels(labels)
+		route.SetAnnotations(ingressAnnotations(params, nil))
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// exposedPorts returns the ports inferred from the receivers of the configuration which can be exposed over HTTP,
+// sorted by name so that the rules don't change from one reconciliation to the next.
+func exposedPorts(params Params) []corev1.ServicePort {
+	config, err := adapters.ConfigFromString(params.Instance.Spec.Config)
+	if err != nil {
+		params.Log.Error(err, "couldn't extract the configuration from the context")
+		return nil
+	}
+
+	ports, err := adapters.ConfigToReceiverPorts(params.Log, config)
+	if err != nil {
+		params.Log.Error(err, "couldn't build the ingress for this instance")
+		return nil
+	}
+
+	exposed := []corev1.ServicePort{}
+	for _, port := range ports {
+		if port.Protocol == "" || port.Protocol == corev1.ProtocolTCP {
+			exposed = append(exposed, port)
+		}
+	}
+	sort.Slice(exposed, func(i, j int) bool {
+		return exposed[i].Name < exposed[j].Name
+	})
+	return exposed
+}
+
+func portHost(params Params, port corev1.ServicePort) string {
+	return fmt.Sprintf("%s.%s", port.Name, params.Instance.Spec.Ingress.Hostname)
+}
+
+func isGRPC(port corev1.ServicePort) bool {
+	return port.AppProtocol != nil && *port.AppProtocol == "grpc"
+}
+
+// ingressAnnotations returns a copy of the annotations of the ingress configuration, with the extra annotations.
+func ingressAnnotations(params Params, extra map[string]string) map[string]string {
+	annotations := map[string]string{}
+	for k, v := range params.Instance.Spec.Ingress.Annotations {
+		annotations[k] = v
+	}
+	for k, v := range extra {
+		annotations[k] = v
+	}
+	return annotations
+}
@@ -0,0 +1,198 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/internal/config"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/platform"
+)
+
+const ingressConfig = `receivers:
+  otlp:
+    protocols:
+      grpc:
+      http:
+  jaeger:
+    protocols:
+      thrift_compact:
+exporters:
+  logging:
+service:
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      exporters: [logging]
+`
+
+func ingressParams() Params {
+	param := params()
+	param.Instance.Spec.Config = ingressConfig
+	param.Instance.Spec.Ingress = v1alpha1.Ingress{
+		Type:        v1alpha1.IngressTypeIngress,
+		Hostname:    "example.com",
+		Annotations: map[string]string{"team": "observability"},
+	}
+	return param
+}
+
+func TestDesiredIngressList(t *testing.T) {
+	param := ingressParams()
+	className := "nginx"
+	param.Instance.Spec.Ingress.IngressClassName = &className
+
+	ingresses := desiredIngressList(param)
+	require.Len(t, ingresses, 2)
+
+	http := ingresses[0]
+	assert.Equal(t, "test-ingress", http.Name)
+	assert.Equal(t, map[string]string{"team": "observability"}, http.Annotations)
+	assert.Equal(t, &className, http.Spec.IngressClassName)
+	require.Len(t, http.Spec.Rules, 2)
+	assert.Equal(t, "otlp-http.example.com", http.Spec.Rules[0].Host)
+	backend := http.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	assert.Equal(t, "test-collector", backend.Name)
+	assert.Equal(t, int32(4318), backend.Port.Number)
+	assert.Equal(t, "otlp-http-legacy.example.com", http.Spec.Rules[1].Host)
+
+	grpc := ingresses[1]
+	assert.Equal(t, "test-grpc-ingress", grpc.Name)
+	assert.Equal(t, map[string]string{"team": "observability", grpcBackendProtocolAnnotation: "GRPC"}, grpc.Annotations)
+	require.Len(t, grpc.Spec.Rules, 1)
+	assert.Equal(t, "otlp-grpc.example.com", grpc.Spec.Rules[0].Host)
+	assert.Equal(t, int32(4317), grpc.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number)
+
+	// the annotations of the instance are left untouched
+	assert.Equal(t, map[string]string{"team": "observability"}, param.Instance.Spec.Ingress.Annotations)
+}
+
+func TestDesiredRouteList(t *testing.T) {
+	param := ingressParams()
+	param.Instance.Spec.Ingress.TLS = []networkingv1.IngressTLS{{}}
+
+	routes := desiredRouteList(param)
+	// the UDP port of the jaeger receiver isn't exposed
+	require.Len(t, routes, 3)
+
+	route := routes[0]
+	assert.Equal(t, RouteGVK, route.GroupVersionKind())
+	assert.Equal(t, "test-otlp-grpc-route", route.GetName())
+	assert.Equal(t, map[string]string{"team": "observability"}, route.GetAnnotations())
+
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	assert.Equal(t, "otlp-grpc.example.com", host)
+	service, _, _ := unstructured.NestedString(route.Object, "spec", "to", "name")
+	assert.Equal(t, "test-collector", service)
+	targetPort, _, _ := unstructured.NestedInt64(route.Object, "spec", "port", "targetPort")
+	assert.Equal(t, int64(4317), targetPort)
+	termination, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "termination")
+	assert.Equal(t, "edge", termination)
+
+	assert.Equal(t, "test-otlp-http-route", routes[1].GetName())
+	assert.Equal(t, "test-otlp-http-legacy-route", routes[2].GetName())
+	// the legacy port points to the port of the pods it's served on
+	targetPort, _, _ = unstructured.NestedInt64(routes[2].Object, "spec", "port", "targetPort")
+	assert.Equal(t, int64(4318), targetPort)
+}
+
+func TestIngresses(t *testing.T) {
+	param := ingressParams()
+	param.Instance.Name = "ingresses"
+
+	t.Run("should create the ingresses", func(t *testing.T) {
+		require.NoError(t, Ingresses(context.Background(), param))
+
+		for _, name := range []string{"ingresses-ingress", "ingresses-grpc-ingress"} {
+			actual := networkingv1.Ingress{}
+			exists, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: name})
+			assert.NoError(t, err)
+			assert.True(t, exists)
+			assert.Equal(t, instanceUID, actual.OwnerReferences[0].UID)
+		}
+	})
+
+	t.Run("should delete the ingresses once the receivers aren't exposed", func(t *testing.T) {
+		disabled := param
+		disabled.Instance.Spec.Ingress.Type = ""
+		require.NoError(t, Ingresses(context.Background(), disabled))
+
+		for _, name := range []string{"ingresses-ingress", "ingresses-grpc-ingress"} {
+			exists, err := populateObjectIfExists(t, &networkingv1.Ingress{}, types.NamespacedName{Namespace: "default", Name: name})
+			assert.NoError(t, err)
+			assert.False(t, exists)
+		}
+	})
+}
+
+func TestIngressesPlatformSwitch(t *testing.T) {
+	param := ingressParams()
+	param.Instance.Name = "switch"
+	ingressNames := []string{"switch-ingress", "switch-grpc-ingress"}
+	routeNames := []string{"switch-otlp-grpc-route", "switch-otlp-http-route", "switch-otlp-http-legacy-route"}
+
+	routeExists := func(name string) bool {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(RouteGVK)
+		exists, err := populateObjectIfExists(t, route, types.NamespacedName{Namespace: "default", Name: name})
+		require.NoError(t, err)
+		return exists
+	}
+	ingressExists := func(name string) bool {
+		exists, err := populateObjectIfExists(t, &networkingv1.Ingress{}, types.NamespacedName{Namespace: "default", Name: name})
+		require.NoError(t, err)
+		return exists
+	}
+
+	t.Run("should create the ingresses before the platform is detected", func(t *testing.T) {
+		require.NoError(t, Ingresses(context.Background(), param))
+
+		for _, name := range ingressNames {
+			assert.True(t, ingressExists(name), name)
+		}
+	})
+
+	t.Run("should replace the ingresses with routes on OpenShift", func(t *testing.T) {
+		openshift := param
+		openshift.Config = config.New(config.WithPlatform(platform.OpenShift))
+		require.NoError(t, Ingresses(context.Background(), openshift))
+
+		for _, name := range ingressNames {
+			assert.False(t, ingressExists(name), name)
+		}
+		for _, name := range routeNames {
+			assert.True(t, routeExists(name), name)
+		}
+	})
+
+	t.Run("should replace the routes with ingresses once OpenShift isn't detected", func(t *testing.T) {
+		require.NoError(t, Ingresses(context.Background(), param))
+
+		for _, name := range routeNames {
+			assert.False(t, routeExists(name), name)
+		}
+		for _, name := range ingressNames {
+			assert.True(t, ingressExists(name), name)
+		}
+	})
+}
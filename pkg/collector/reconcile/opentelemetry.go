@@ -33,7 +33,8 @@ import (
 // making params.Instance obsolete. Default values should be set in the Defaulter webhook, this should only be used
 // for the Status, which can't be set by the defaulter.
 func Self(ctx context.Context, params Params) error {
-	changed := params.Instance
+	// the conditions are updated in place, they must not be shared with the instance the patch is computed from
+	changed := *params.Instance.DeepCopy()
 
 	// this field is only changed for new instances: on existing instances this
 	// field is reconciled when the operator is first started, i.e. during
@@ -47,6 +48,10 @@ func Self(ctx context.Context, params Params) error {
 		return fmt.Errorf("failed to update the scale subresource status for the OpenTelemetry CR: %w", err)
 	}
 
+	if err := updateConditions(ctx, params, &changed); err != nil {
+		return fmt.Errorf("failed to update the status conditions for the OpenTelemetry CR: %w", err)
+	}
+
 	statusPatch := client.MergeFrom(&params.Instance)
 	if err := params.Client.Status().Patch(ctx, &changed, statusPatch); err != nil {
 		return fmt.Errorf("failed to apply status changes to the OpenTelemetry CR: %w", err)
@@ -55,6 +60,25 @@ func Self(ctx context.Context, params Params) error {
 	return nil
 }
 
+// Failure records the error of a failed reconciliation in the Degraded and ConfigValid conditions of this instance.
+// The other conditions are left as they are, as the tasks after the failed one didn't run.
+func Failure(ctx context.Context, params Params, reconcileErr error) error {
+	changed := *params.Instance.DeepCopy()
+
+	configErr := validateConfig(params)
+	degraded := metav1.Condition{Type: v1alpha1.ConditionTypeDegraded, Status: metav1.ConditionTrue, Reason: reasonReconcileFailed, Message: reconcileErr.Error()}
+	if configErr != nil {
+		degraded.Reason = reasonInvalidConfig
+	}
+	setConditions(&changed, configValidCondition(configErr), degraded)
+
+	statusPatch := client.MergeFrom(&params.Instance)
+	if err := params.Client.Status().Patch(ctx, &changed, statusPatch); err != nil {
+		return fmt.Errorf("failed to apply status changes to the OpenTelemetry CR: %w", err)
+	}
+	return nil
+}
+
 func updateScaleSubResourceStatus(ctx context.Context, cli client.Client, changed *v1alpha1.OpenTelemetryCollector) error {
 	mode := changed.Spec.Mode
 	if mode != v1alpha1.ModeDeployment && mode != v1alpha1.ModeStatefulSet {
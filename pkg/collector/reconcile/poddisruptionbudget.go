@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/open-telemetry/opentelemetry-operator/pkg/autodetect"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator"
+)
+
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
+// PodDisruptionBudgets reconciles the pod disruption budget(s) required for the instance in the current context.
+func PodDisruptionBudgets(ctx context.Context, params Params) error {
+	desired := []client.Object{}
+
+	// a single replica can't be protected from disruptions without blocking the drain of its node
+	ta := params.Instance.Spec.TargetAllocator
+	if ta.Enabled && ta.Replicas != nil && *ta.Replicas > 1 {
+		pdb := targetallocator.PodDisruptionBudget(params.Config, params.Log, params.Instance)
+		desired = append(desired, &pdb)
+	}
+
+	var list client.ObjectList = &policyv1.PodDisruptionBudgetList{}
+	if params.Config.PolicyVersion() == autodetect.PolicyV1Beta1 {
+		list = &policyv1beta1.PodDisruptionBudgetList{}
+		for i := range desired {
+			desired[i] = podDisruptionBudgetV1Beta1(desired[i].(*policyv1.PodDisruptionBudget))
+		}
+	}
+
+	if err := reconcileObjects(ctx, params, list, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the pod disruption budgets: %w", err)
+	}
+
+	return nil
+}
+
+// podDisruptionBudgetV1Beta1 converts the pod disruption budget for the clusters before Kubernetes 1.21, which don't
+// serve policy/v1 yet.
+func podDisruptionBudgetV1Beta1(pdb *policyv1.PodDisruptionBudget) *policyv1beta1.PodDisruptionBudget {
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: pdb.ObjectMeta,
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable:   pdb.Spec.MinAvailable,
+			Selector:       pdb.Spec.Selector,
+			MaxUnavailable: pdb.Spec.MaxUnavailable,
+		},
+	}
+}
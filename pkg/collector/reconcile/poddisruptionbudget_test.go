@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator"
+)
+
+func TestExpectedPodDisruptionBudgets(t *testing.T) {
+	param, err := newParams("test/test-img", "")
+	assert.NoError(t, err)
+	replicas := int32(3)
+	param.Instance.Spec.TargetAllocator.Replicas = &replicas
+	expectedPDB := targetallocator.PodDisruptionBudget(param.Config, logger, param.Instance)
+
+	t.Run("should create target allocator pod disruption budget", func(t *testing.T) {
+		err := PodDisruptionBudgets(context.Background(), param)
+		assert.NoError(t, err)
+
+		actual := policyv1.PodDisruptionBudget{}
+		exists, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "test-targetallocator"})
+
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, instanceUID, actual.OwnerReferences[0].UID)
+		assert.Equal(t, expectedPDB.Spec.Selector, actual.Spec.Selector)
+	})
+
+	t.Run("should delete target allocator pod disruption budget with a single replica", func(t *testing.T) {
+		singleReplica, err := newParams("test/test-img", "")
+		assert.NoError(t, err)
+
+		err = PodDisruptionBudgets(context.Background(), singleReplica)
+		assert.NoError(t, err)
+
+		exists, err := populateObjectIfExists(t, &policyv1.PodDisruptionBudget{}, types.NamespacedName{Namespace: "default", Name: "test-targetallocator"})
+
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestPodDisruptionBudgetV1Beta1(t *testing.T) {
+	param, err := newParams("test/test-img", "")
+	assert.NoError(t, err)
+	pdb := targetallocator.PodDisruptionBudget(param.Config, logger, param.Instance)
+
+	actual := podDisruptionBudgetV1Beta1(&pdb)
+
+	assert.Equal(t, pdb.ObjectMeta, actual.ObjectMeta)
+	assert.Equal(t, pdb.Spec.Selector, actual.Spec.Selector)
+	assert.Equal(t, pdb.Spec.MaxUnavailable, actual.Spec.MaxUnavailable)
+}
@@ -20,12 +20,9 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
-	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
@@ -38,30 +35,25 @@ import (
 
 // Services reconciles the service(s) required for the instance in the current context.
 func Services(ctx context.Context, params Params) error {
-	desired := []corev1.Service{}
+	desired := []client.Object{}
 	if params.Instance.Spec.Mode != v1alpha1.ModeSidecar {
 		type builder func(context.Context, Params) *corev1.Service
 		for _, builder := range []builder{desiredService, headless, monitoringService} {
 			svc := builder(ctx, params)
 			// add only the non-nil to the list
 			if svc != nil {
-				desired = append(desired, *svc)
+				desired = append(desired, svc)
 			}
 		}
 	}
 
 	if params.Instance.Spec.TargetAllocator.Enabled {
-		desired = append(desired, desiredTAService(params))
+		svc := desiredTAService(params)
+		desired = append(desired, &svc)
 	}
 
-	// first, handle the create/update parts
-	if err := expectedServices(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the expected services: %w", err)
-	}
-
-	// then, delete the extra objects
-	if err := deleteServices(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the services to be deleted: %w", err)
+	if err := reconcileObjects(ctx, params, &corev1.ServiceList{}, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the services: %w", err)
 	}
 
 	return nil
@@ -134,6 +126,20 @@ func desiredTAService(params Params) corev1.Service {
 	selector := targetallocator.Labels(params.Instance)
 	selector["app.kubernetes.io/name"] = naming.TargetAllocator(params.Instance)
 
+	ports := []corev1.ServicePort{{
+		Name:       "targetallocation",
+		Port:       targetallocator.ServicePort(params.Instance),
+		TargetPort: intstr.FromInt(8080),
+	}}
+	if params.Instance.Spec.TargetAllocator.TLS != nil {
+		// the metrics can't be scraped from the HTTPS endpoint without the collectors' client certificate
+		ports = append(ports, corev1.ServicePort{
+			Name:       "metrics",
+			Port:       targetallocator.MetricsPort,
+			TargetPort: intstr.FromInt(int(targetallocator.MetricsPort)),
+		})
+	}
+
 	return corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      naming.TAService(params.Instance),
@@ -142,11 +148,7 @@ func desiredTAService(params Params) corev1.Service {
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: selector,
-			Ports: []corev1.ServicePort{{
-				Name:       "targetallocation",
-				Port:       80,
-				TargetPort: intstr.FromInt(8080),
-			}},
+			Ports:    ports,
 		},
 	}
 }
@@ -197,91 +199,6 @@ func monitoringService(ctx context.Context, params Params) *corev1.Service {
 	}
 }
 
-func expectedServices(ctx context.Context, params Params, expected []corev1.Service) error {
-	for _, obj := range expected {
-		desired := obj
-
-		if err := controllerutil.SetControllerReference(&params.Instance, &desired, params.Scheme); err != nil {
-			return fmt.Errorf("failed to set controller reference: %w", err)
-		}
-
-		existing := &corev1.Service{}
-		nns := types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}
-		err := params.Client.Get(ctx, nns, existing)
-		if err != nil && k8serrors.IsNotFound(err) {
-			if err := params.Client.Create(ctx, &desired); err != nil {
-				return fmt.Errorf("failed to create: %w", err)
-			}
-			params.Log.V(2).Info("created", "service.name", desired.Name, "service.namespace", desired.Namespace)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to get: %w", err)
-		}
-
-		// it exists already, merge the two if the end result isn't identical to the existing one
-		updated := existing.DeepCopy()
-		if updated.Annotations == nil {
-			updated.Annotations = map[string]string{}
-		}
-		if updated.Labels == nil {
-			updated.Labels = map[string]string{}
-		}
-		updated.ObjectMeta.OwnerReferences = desired.ObjectMeta.OwnerReferences
-
-		for k, v := range desired.ObjectMeta.Annotations {
-			updated.ObjectMeta.Annotations[k] = v
-		}
-		for k, v := range desired.ObjectMeta.Labels {
-			updated.ObjectMeta.Labels[k] = v
-		}
-		updated.Spec.Ports = desired.Spec.Ports
-
-		patch := client.MergeFrom(existing)
-
-		if err := params.Client.Patch(ctx, updated, patch); err != nil {
-			return fmt.Errorf("failed to apply changes: %w", err)
-		}
-
-		params.Log.V(2).Info("applied", "service.name", desired.Name, "service.namespace", desired.Namespace)
-	}
-
-	return nil
-}
-
-func deleteServices(ctx context.Context, params Params, expected []corev1.Service) error {
-	opts := []client.ListOption{
-		client.InNamespace(params.Instance.Namespace),
-		client.MatchingLabels(map[string]string{
-			"app.kubernetes.io/instance":   fmt.Sprintf("%s.%s", params.Instance.Namespace, params.Instance.Name),
-			"app.kubernetes.io/managed-by": "opentelemetry-operator",
-		}),
-	}
-	list := &corev1.ServiceList{}
-	if err := params.Client.List(ctx, list, opts...); err != nil {
-		return fmt.Errorf("failed to list: %w", err)
-	}
-
-	for i := range list.Items {
-		existing := list.Items[i]
-		del := true
-		for _, keep := range expected {
-			if keep.Name == existing.Name && keep.Namespace == existing.Namespace {
-				del = false
-				break
-			}
-		}
-
-		if del {
-			if err := params.Client.Delete(ctx, &existing); err != nil {
-				return fmt.Errorf("failed to delete: %w", err)
-			}
-			params.Log.V(2).Info("deleted", "service.name", existing.Name, "service.namespace", existing.Namespace)
-		}
-	}
-
-	return nil
-}
-
 func filterPort(logger logr.Logger, candidate corev1.ServicePort, portNumbers map[int32]bool, portNames map[string]bool) *corev1.ServicePort {
 	if portNumbers[candidate.Port] {
 		return nil
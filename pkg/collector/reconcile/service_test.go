@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
@@ -134,7 +135,8 @@ func TestDesiredService(t *testing.T) {
 
 func TestExpectedServices(t *testing.T) {
 	t.Run("should create the service", func(t *testing.T) {
-		err := expectedServices(context.Background(), params(), []v1.Service{service("test-collector", params().Instance.Spec.Ports)})
+		desired := service("test-collector", params().Instance.Spec.Ports)
+		err := expectedObjects(context.Background(), params(), []client.Object{&desired})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.Service{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -155,7 +157,8 @@ func TestExpectedServices(t *testing.T) {
 		}
 
 		ports := append(params().Instance.Spec.Ports, extraPorts)
-		err := expectedServices(context.Background(), params(), []v1.Service{service("test-collector", ports)})
+		desired := service("test-collector", ports)
+		err := expectedObjects(context.Background(), params(), []client.Object{&desired})
 		assert.NoError(t, err)
 
 		actual := v1.Service{}
@@ -167,6 +170,58 @@ func TestExpectedServices(t *testing.T) {
 		assert.Contains(t, actual.Spec.Ports, extraPorts)
 
 	})
+	t.Run("should leave the fields set by others alone", func(t *testing.T) {
+		actual := v1.Service{}
+		_, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "test-collector"})
+		assert.NoError(t, err)
+		actual.Annotations = map[string]string{"team": "observability"}
+		assert.NoError(t, k8sClient.Update(context.Background(), &actual))
+
+		desired := service("test-collector", params().Instance.Spec.Ports)
+		err = expectedObjects(context.Background(), params(), []client.Object{&desired})
+		assert.NoError(t, err)
+
+		_, err = populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "test-collector"})
+		assert.NoError(t, err)
+		assert.Equal(t, "observability", actual.Annotations["team"])
+	})
+	t.Run("should update the selector and type of the service", func(t *testing.T) {
+		serviceInstance := service("test-collector", params().Instance.Spec.Ports)
+		createObjectIfNotExists(t, "test-collector", &serviceInstance)
+
+		desired := service("test-collector", params().Instance.Spec.Ports)
+		desired.Spec.Selector = map[string]string{"app.kubernetes.io/name": "test-collector-changed"}
+		desired.Spec.Type = v1.ServiceTypeNodePort
+		err := expectedObjects(context.Background(), params(), []client.Object{&desired})
+		assert.NoError(t, err)
+
+		actual := v1.Service{}
+		exists, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "test-collector"})
+
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, desired.Spec.Selector, actual.Spec.Selector)
+		assert.Equal(t, v1.ServiceTypeNodePort, actual.Spec.Type)
+	})
+	t.Run("should remove the ports set before the operator applied the service", func(t *testing.T) {
+		extraPort := v1.ServicePort{Name: "port-web", Protocol: "TCP", Port: 8080, TargetPort: intstr.FromInt(8080)}
+		updated := service("upgraded-collector", append(params().Instance.Spec.Ports, extraPort))
+		// the operator used to create and update the objects it owns without applying them
+		assert.NoError(t, k8sClient.Create(context.Background(), &updated, client.FieldOwner(legacyFieldManager)))
+
+		desired := service("upgraded-collector", params().Instance.Spec.Ports)
+		err := expectedObjects(context.Background(), params(), []client.Object{&desired})
+		assert.NoError(t, err)
+
+		actual := v1.Service{}
+		exists, err := populateObjectIfExists(t, &actual, types.NamespacedName{Namespace: "default", Name: "upgraded-collector"})
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.NotContains(t, actual.Spec.Ports, extraPort)
+		for _, entry := range actual.ManagedFields {
+			assert.NotEqual(t, legacyFieldManager, entry.Manager)
+		}
+	})
 }
 
 func TestDeleteServices(t *testing.T) {
@@ -183,7 +238,7 @@ func TestDeleteServices(t *testing.T) {
 		assert.True(t, exists)
 
 		desired := desiredService(context.Background(), params())
-		err = deleteServices(context.Background(), params(), []v1.Service{*desired})
+		err = deleteObjects(context.Background(), params(), &v1.ServiceList{}, []client.Object{desired})
 		assert.NoError(t, err)
 
 		exists, err = populateObjectIfExists(t, &v1.Service{}, types.NamespacedName{Namespace: "default", Name: "delete-service-collector"})
@@ -213,6 +268,24 @@ func TestMonitoringService(t *testing.T) {
 	})
 }
 
+func TestDesiredTAService(t *testing.T) {
+	t.Run("should only expose the allocation port without TLS", func(t *testing.T) {
+		actual := desiredTAService(params())
+		assert.Len(t, actual.Spec.Ports, 1)
+		assert.Equal(t, int32(80), actual.Spec.Ports[0].Port)
+	})
+
+	t.Run("should expose the metrics port with TLS", func(t *testing.T) {
+		param := params()
+		param.Instance.Spec.TargetAllocator.TLS = &v1alpha1.OpenTelemetryTargetAllocatorTLS{SecretName: "ta-cert"}
+		actual := desiredTAService(param)
+		assert.Equal(t, []v1.ServicePort{
+			{Name: "targetallocation", Port: 443, TargetPort: intstr.FromInt(8080)},
+			{Name: "metrics", Port: 8081, TargetPort: intstr.FromInt(8081)},
+		}, actual.Spec.Ports)
+	})
+}
+
 func service(name string, ports []v1.ServicePort) v1.Service {
 	labels := collector.Labels(params().Instance, []string{})
 	labels["app.kubernetes.io/name"] = name
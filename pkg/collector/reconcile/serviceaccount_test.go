@@ -22,6 +22,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
 )
@@ -29,7 +30,7 @@ import (
 func TestExpectedServiceAccounts(t *testing.T) {
 	t.Run("should create service account", func(t *testing.T) {
 		desired := collector.ServiceAccount(params().Instance)
-		err := expectedServiceAccounts(context.Background(), params(), []v1.ServiceAccount{desired})
+		err := expectedObjects(context.Background(), params(), []client.Object{&desired})
 		assert.NoError(t, err)
 
 		exists, err := populateObjectIfExists(t, &v1.ServiceAccount{}, types.NamespacedName{Namespace: "default", Name: "test-collector"})
@@ -50,7 +51,8 @@ func TestExpectedServiceAccounts(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, exists)
 
-		err = expectedServiceAccounts(context.Background(), params(), []v1.ServiceAccount{collector.ServiceAccount(params().Instance)})
+		desired := collector.ServiceAccount(params().Instance)
+		err = expectedObjects(context.Background(), params(), []client.Object{&desired})
 		assert.NoError(t, err)
 
 		actual := v1.ServiceAccount{}
@@ -77,7 +79,8 @@ func TestDeleteServiceAccounts(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, exists)
 
-		err = deleteServiceAccounts(context.Background(), params(), []v1.ServiceAccount{collector.ServiceAccount(params().Instance)})
+		desired := collector.ServiceAccount(params().Instance)
+		err = deleteObjects(context.Background(), params(), &v1.ServiceAccountList{}, []client.Object{&desired})
 		assert.NoError(t, err)
 
 		exists, err = populateObjectIfExists(t, &v1.ServiceAccount{}, types.NamespacedName{Namespace: "default", Name: "test-delete-collector"})
@@ -101,7 +104,8 @@ func TestDeleteServiceAccounts(t *testing.T) {
 		assert.NoError(t, err)
 		assert.True(t, exists)
 
-		err = deleteServiceAccounts(context.Background(), params(), []v1.ServiceAccount{collector.ServiceAccount(params().Instance)})
+		desired := collector.ServiceAccount(params().Instance)
+		err = deleteObjects(context.Background(), params(), &v1.ServiceAccountList{}, []client.Object{&desired})
 		assert.NoError(t, err)
 
 		exists, err = populateObjectIfExists(t, &v1.ServiceAccount{}, types.NamespacedName{Namespace: "default", Name: "test-delete-collector"})
@@ -19,10 +19,7 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
-	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector"
 )
@@ -31,105 +28,19 @@ import (
 
 // StatefulSets reconciles the stateful set(s) required for the instance in the current context.
 func StatefulSets(ctx context.Context, params Params) error {
-
-	desired := []appsv1.StatefulSet{}
+	desired := []client.Object{}
 	if params.Instance.Spec.Mode == "statefulset" {
-		desired = append(desired, collector.StatefulSet(params.Config, params.Log, params.Instance))
-	}
-
-	// first, handle the create/update parts
-	if err := expectedStatefulSets(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the expected stateful sets: %w", err)
-	}
-
-	// then, delete the extra objects
-	if err := deleteStatefulSets(ctx, params, desired); err != nil {
-		return fmt.Errorf("failed to reconcile the stateful sets to be deleted: %w", err)
-	}
-
-	return nil
-}
-
-func expectedStatefulSets(ctx context.Context, params Params, expected []appsv1.StatefulSet) error {
-	for _, obj := range expected {
-		desired := obj
-
-		if err := controllerutil.SetControllerReference(&params.Instance, &desired, params.Scheme); err != nil {
-			return fmt.Errorf("failed to set controller reference: %w", err)
-		}
-
-		existing := &appsv1.StatefulSet{}
-		nns := types.NamespacedName{Namespace: desired.Namespace, Name: desired.Name}
-		err := params.Client.Get(ctx, nns, existing)
-		if err != nil && k8serrors.IsNotFound(err) {
-			if err := params.Client.Create(ctx, &desired); err != nil {
-				return fmt.Errorf("failed to create: %w", err)
-			}
-			params.Log.V(2).Info("created", "statefulset.name", desired.Name, "statefulset.namespace", desired.Namespace)
-			continue
-		} else if err != nil {
-			return fmt.Errorf("failed to get: %w", err)
-		}
-
-		// it exists already, merge the two if the end result isn't identical to the existing one
-		updated := existing.DeepCopy()
-		if updated.Annotations == nil {
-			updated.Annotations = map[string]string{}
-		}
-		if updated.Labels == nil {
-			updated.Labels = map[string]string{}
+		configHash, err := collectorConfigHash(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to compute the config hash: %w", err)
 		}
-
-		updated.Spec = desired.Spec
-		updated.ObjectMeta.OwnerReferences = desired.ObjectMeta.OwnerReferences
-
-		for k, v := range desired.ObjectMeta.Annotations {
-			updated.ObjectMeta.Annotations[k] = v
-		}
-		for k, v := range desired.ObjectMeta.Labels {
-			updated.ObjectMeta.Labels[k] = v
-		}
-
-		patch := client.MergeFrom(existing)
-		if err := params.Client.Patch(ctx, updated, patch); err != nil {
-			return fmt.Errorf("failed to apply changes: %w", err)
-		}
-
-		params.Log.V(2).Info("applied", "statefulset.name", desired.Name, "statefulset.namespace", desired.Namespace)
-	}
-
-	return nil
-}
-
-func deleteStatefulSets(ctx context.Context, params Params, expected []appsv1.StatefulSet) error {
-	opts := []client.ListOption{
-		client.InNamespace(params.Instance.Namespace),
-		client.MatchingLabels(map[string]string{
-			"app.kubernetes.io/instance":   fmt.Sprintf("%s.%s", params.Instance.Namespace, params.Instance.Name),
-			"app.kubernetes.io/managed-by": "opentelemetry-operator",
-		}),
+		ss := collector.StatefulSet(params.Config, params.Log, params.Instance)
+		setConfigHash(&ss.Spec.Template, configHash)
+		desired = append(desired, &ss)
 	}
-	list := &appsv1.StatefulSetList{}
-	if err := params.Client.List(ctx, list, opts...); err != nil {
-		return fmt.Errorf("failed to list: %w", err)
-	}
-
-	for i := range list.Items {
-		existing := list.Items[i]
-		del := true
-		for _, keep := range expected {
-			if keep.Name == existing.Name && keep.Namespace == existing.Namespace {
-				del = false
-				break
-			}
-		}
 
-		if del {
-			if err := params.Client.Delete(ctx, &existing); err != nil {
-				return fmt.Errorf("failed to delete: %w", err)
-			}
-			params.Log.V(2).Info("deleted", "statefulset.name", existing.Name, "statefulset.namespace", existing.Namespace)
-		}
+	if err := reconcileObjects(ctx, params, &appsv1.StatefulSetList{}, desired); err != nil {
+		return fmt.Errorf("failed to reconcile the stateful sets: %w", err)
 	}
 
 	return nil
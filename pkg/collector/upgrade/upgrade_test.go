@@ -16,8 +16,11 @@ package upgrade_test
 
 import (
 	"context"
+	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -146,6 +149,23 @@ func TestVersionsShouldNotBeChanged(t *testing.T) {
 	}
 }
 
+func TestVersionsFileCoversLatestUpgrade(t *testing.T) {
+	// the instances are created with the version of versions.txt, they'd get upgraded on every start of the
+	// operator if it were older than the latest upgrade
+	content, err := ioutil.ReadFile("../../../versions.txt")
+	require.NoError(t, err)
+
+	var otelColVersion string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "opentelemetry-collector=") {
+			otelColVersion = strings.TrimPrefix(line, "opentelemetry-collector=")
+		}
+	}
+	v, err := semver.NewVersion(otelColVersion)
+	require.NoError(t, err)
+	assert.False(t, v.LessThan(&upgrade.Latest.Version), "versions.txt has %s, older than the latest upgrade %s", v, upgrade.Latest.Version)
+}
+
 func makeOtelcol(nsn types.NamespacedName) v1alpha1.OpenTelemetryCollector {
 	return v1alpha1.OpenTelemetryCollector{
 		ObjectMeta: metav1.ObjectMeta{
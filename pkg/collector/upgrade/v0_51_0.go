@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+)
+
+func upgrade0_51_0(u VersionUpgrade, otelcol *v1alpha1.OpenTelemetryCollector) (*v1alpha1.OpenTelemetryCollector, error) {
+	promCR := &otelcol.Spec.TargetAllocator.PrometheusCR
+	if !promCR.Enabled {
+		return otelcol, nil
+	}
+
+	// Before v0.51.0, the TargetAllocator retrieved all the ServiceMonitors and PodMonitors. Like in the
+	// Prometheus Operator, a selector which isn't set now selects none of them, so set empty selectors
+	// to keep retrieving all of them.
+	var defaulted []string
+	if promCR.ServiceMonitorSelector == nil {
+		promCR.ServiceMonitorSelector = &metav1.LabelSelector{}
+		defaulted = append(defaulted, "serviceMonitorSelector")
+	}
+	if promCR.PodMonitorSelector == nil {
+		promCR.PodMonitorSelector = &metav1.LabelSelector{}
+		defaulted = append(defaulted, "podMonitorSelector")
+	}
+
+	if len(defaulted) > 0 {
+		u.Recorder.Event(otelcol, "Normal", "Upgrade", fmt.Sprintf("upgrade to v0.51.0 set the otelcol.spec.targetAllocator.prometheusCR selectors %v to {}, so that all the ServiceMonitors and PodMonitors are still retrieved.", defaulted))
+	}
+	return otelcol, nil
+}
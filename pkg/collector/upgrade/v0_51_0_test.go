@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgrade_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/internal/version"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/upgrade"
+)
+
+func Test0_51_0Upgrade(t *testing.T) {
+	// prepare
+	nsn := types.NamespacedName{Name: "my-instance", Namespace: "default"}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+	existing := v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nsn.Name,
+			Namespace: nsn.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "opentelemetry-operator",
+			},
+		},
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Enabled: true,
+				PrometheusCR: v1alpha1.OpenTelemetryTargetAllocatorPrometheusCR{
+					Enabled:            true,
+					PodMonitorSelector: selector,
+				},
+			},
+		},
+	}
+	existing.Status.Version = "0.50.0"
+
+	// test
+	up := &upgrade.VersionUpgrade{
+		Log:      logger,
+		Version:  version.Get(),
+		Client:   nil,
+		Recorder: record.NewFakeRecorder(upgrade.RecordBufferSize),
+	}
+	res, err := up.ManagedInstance(context.Background(), existing)
+	assert.NoError(t, err)
+
+	// verify
+	assert.Equal(t, &metav1.LabelSelector{}, res.Spec.TargetAllocator.PrometheusCR.ServiceMonitorSelector)
+	assert.Equal(t, selector, res.Spec.TargetAllocator.PrometheusCR.PodMonitorSelector)
+	assert.Nil(t, res.Spec.TargetAllocator.PrometheusCR.ProbeSelector)
+}
+
+func Test0_51_0UpgradeWithoutPrometheusCR(t *testing.T) {
+	// prepare
+	existing := v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance",
+			Namespace: "default",
+		},
+	}
+	existing.Status.Version = "0.50.0"
+
+	// test
+	up := &upgrade.VersionUpgrade{
+		Log:      logger,
+		Version:  version.Get(),
+		Client:   nil,
+		Recorder: record.NewFakeRecorder(upgrade.RecordBufferSize),
+	}
+	res, err := up.ManagedInstance(context.Background(), existing)
+	assert.NoError(t, err)
+
+	// verify
+	assert.Nil(t, res.Spec.TargetAllocator.PrometheusCR.ServiceMonitorSelector)
+	assert.Nil(t, res.Spec.TargetAllocator.PrometheusCR.PodMonitorSelector)
+}
+
+func Test0_51_0UpgradeNotAppliedToNewInstances(t *testing.T) {
+	// prepare
+	existing := v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Enabled:      true,
+				PrometheusCR: v1alpha1.OpenTelemetryTargetAllocatorPrometheusCR{Enabled: true},
+			},
+		},
+	}
+	// the instances are created with the current version, see TestVersionsFileCoversLatestUpgrade
+	currentV := version.Get()
+	currentV.OpenTelemetryCollector = upgrade.Latest.String()
+	existing.Status.Version = currentV.OpenTelemetryCollector
+
+	up := &upgrade.VersionUpgrade{
+		Log:      logger,
+		Version:  currentV,
+		Client:   nil,
+		Recorder: record.NewFakeRecorder(upgrade.RecordBufferSize),
+	}
+
+	// test: the upgrade runs on every start of the operator
+	res, err := up.ManagedInstance(context.Background(), existing)
+	require.NoError(t, err)
+	res, err = up.ManagedInstance(context.Background(), res)
+	require.NoError(t, err)
+
+	// verify
+	assert.Nil(t, res.Spec.TargetAllocator.PrometheusCR.ServiceMonitorSelector)
+	assert.Nil(t, res.Spec.TargetAllocator.PrometheusCR.PodMonitorSelector)
+	assert.Equal(t, currentV.OpenTelemetryCollector, res.Status.Version)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
 	"github.com/open-telemetry/opentelemetry-operator/internal/config"
 	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/targetallocator"
 )
 
 // Volumes builds the volumes for the given instance, including the config map volume.
@@ -42,5 +43,7 @@ func Volumes(cfg config.Config, otelcol v1alpha1.OpenTelemetryCollector) []corev
 		volumes = append(volumes, otelcol.Spec.Volumes...)
 	}
 
+	volumes = append(volumes, targetallocator.CollectorVolumes(otelcol)...)
+
 	return volumes
 }
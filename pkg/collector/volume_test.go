@@ -61,3 +61,26 @@ func TestVolumeAllowsMoreToBeAdded(t *testing.T) {
 	// check that it's the otc-internal volume, with the config map
 	assert.Equal(t, "my-volume", volumes[1].Name)
 }
+
+func TestVolumeTargetAllocatorTLS(t *testing.T) {
+	// prepare
+	otelcol := v1alpha1.OpenTelemetryCollector{
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Enabled: true,
+				TLS:     &v1alpha1.OpenTelemetryTargetAllocatorTLS{SecretName: "ta-cert"},
+			},
+		},
+	}
+	cfg := config.New()
+
+	// test
+	volumes := Volumes(cfg, otelcol)
+	c := Container(cfg, logger, otelcol)
+
+	// verify
+	assert.Len(t, volumes, 2)
+	assert.Equal(t, naming.TACAVolume(), volumes[1].Name)
+	assert.Len(t, c.VolumeMounts, 2)
+	assert.Equal(t, naming.TACAVolume(), c.VolumeMounts[1].Name)
+}
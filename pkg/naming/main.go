@@ -39,6 +39,31 @@ func TAConfigMapVolume() string {
 	return "ta-internal"
 }
 
+// TATLSVolume returns the name to use for the volume holding the TargetAllocator's certificate in the TargetAllocator pod.
+func TATLSVolume() string {
+	return "ta-tls"
+}
+
+// TABearerTokenVolume returns the name to use for the volume holding the bearer token in the TargetAllocator pod.
+func TABearerTokenVolume() string {
+	return "ta-bearer-token"
+}
+
+// TACAVolume returns the name to use for the volume holding the TargetAllocator's CA certificate in the collector pod.
+func TACAVolume() string {
+	return "otc-ta-ca"
+}
+
+// TAClientTLSVolume returns the name to use for the volume holding the client certificate in the collector pod.
+func TAClientTLSVolume() string {
+	return "otc-ta-client-tls"
+}
+
+// TAClientBearerTokenVolume returns the name to use for the volume holding the bearer token in the collector pod.
+func TAClientBearerTokenVolume() string {
+	return "otc-ta-bearer-token"
+}
+
 // Container returns the name to use for the container in the pod.
 func Container() string {
 	return "otc-container"
@@ -83,3 +108,18 @@ func TAService(otelcol v1alpha1.OpenTelemetryCollector) string {
 func ServiceAccount(otelcol v1alpha1.OpenTelemetryCollector) string {
 	return DNSName(Truncate("%s-collector", 63, otelcol.Name))
 }
+
+// Ingress builds the name for the ingress exposing the HTTP receivers of the instance.
+func Ingress(otelcol v1alpha1.OpenTelemetryCollector) string {
+	return DNSName(Truncate("%s-ingress", 63, otelcol.Name))
+}
+
+// GRPCIngress builds the name for the ingress exposing the gRPC receivers of the instance.
+func GRPCIngress(otelcol v1alpha1.OpenTelemetryCollector) string {
+	return DNSName(Truncate("%s-grpc-ingress", 63, otelcol.Name))
+}
+
+// Route builds the name for the route exposing the given port of the instance.
+func Route(otelcol v1alpha1.OpenTelemetryCollector, port string) string {
+	return DNSName(Truncate("%s-%s-route", 63, otelcol.Name, port))
+}
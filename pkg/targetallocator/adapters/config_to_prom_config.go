@@ -15,11 +15,20 @@
 package adapters
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/common/model"
 
 	"github.com/open-telemetry/opentelemetry-operator/pkg/collector/adapters"
 )
 
+// ErrPrometheusCRMultipleReceivers is returned when the jobs of the Prometheus CRs would be scraped by several receivers.
+var ErrPrometheusCRMultipleReceivers = errors.New("the jobs of the Prometheus CRs are scraped by a single prometheus receiver, only one can be configured when they are enabled")
+
 func errorNoComponent(component string) error {
 	return fmt.Errorf("no %s available as part of the configuration", component)
 }
@@ -28,8 +37,25 @@ func errorNotAMap(component string) error {
 	return fmt.Errorf("%s property in the configuration doesn't contain valid %s", component, component)
 }
 
-// ConfigToPromConfig converts the incoming configuration object into a the Prometheus receiver config.
-func ConfigToPromConfig(cfg string) (map[interface{}]interface{}, error) {
+// PrometheusReceiver is the type of the receivers taking part in the target allocation.
+const PrometheusReceiver = "prometheus"
+
+// defaultScrapeInterval and defaultScrapeTimeout are the scrape interval and timeout of the jobs of a Prometheus
+// config which doesn't set any.
+const (
+	defaultScrapeInterval = "1m"
+	defaultScrapeTimeout  = "10s"
+)
+
+// IsPrometheusReceiver returns whether the receiver with the given name, like prometheus or prometheus/infra,
+// is a Prometheus receiver.
+func IsPrometheusReceiver(name string) bool {
+	return name == PrometheusReceiver || strings.HasPrefix(name, PrometheusReceiver+"/")
+}
+
+// ConfigToPromConfigs returns the Prometheus config of every Prometheus receiver of the configuration, indexed by
+// receiver name.
+func ConfigToPromConfigs(cfg string) (map[string]map[interface{}]interface{}, error) {
 	config, err := adapters.ConfigFromString(cfg)
 	if err != nil {
 		return nil, err
@@ -45,25 +71,155 @@ func ConfigToPromConfig(cfg string) (map[interface{}]interface{}, error) {
 		return nil, errorNotAMap("receivers")
 	}
 
-	prometheusProperty, ok := receivers["prometheus"]
-	if !ok {
+	promConfigs := make(map[string]map[interface{}]interface{})
+	for key, prometheusProperty := range receivers {
+		name, ok := key.(string)
+		if !ok || !IsPrometheusReceiver(name) {
+			continue
+		}
+
+		prometheus, ok := prometheusProperty.(map[interface{}]interface{})
+		if !ok {
+			return nil, errorNotAMap(name)
+		}
+
+		prometheusConfigProperty, ok := prometheus["config"]
+		if !ok {
+			return nil, errorNoComponent("prometheusConfig")
+		}
+
+		prometheusConfig, ok := prometheusConfigProperty.(map[interface{}]interface{})
+		if !ok {
+			return nil, errorNotAMap("prometheusConfig")
+		}
+		promConfigs[name] = prometheusConfig
+	}
+	if len(promConfigs) == 0 {
 		return nil, errorNoComponent("prometheus")
 	}
+	return promConfigs, nil
+}
 
-	prometheus, ok := prometheusProperty.(map[interface{}]interface{})
-	if !ok {
-		return nil, errorNotAMap("prometheus")
+// PrometheusReceiverNames returns the names of the receivers of the Prometheus configs, sorted so that the
+// prometheus receiver, if any, comes first.
+func PrometheusReceiverNames(promConfigs map[string]map[interface{}]interface{}) []string {
+	names := make([]string, 0, len(promConfigs))
+	for name := range promConfigs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	prometheusConfigProperty, ok := prometheus["config"]
-	if !ok {
-		return nil, errorNoComponent("prometheusConfig")
+// ConfigToPromConfig converts the incoming configuration object into the Prometheus config of the target allocation,
+// made of the scrape configs of all the Prometheus receivers. The other settings, like the global section, are the
+// ones of the first receiver. The jobs of the other receivers keep the scrape interval and timeout they default to in
+// their receiver, the other global settings must be the same in every receiver. The job names must be unique across
+// the receivers, as the targets of the jobs are served by job name.
+func ConfigToPromConfig(cfg string) (map[interface{}]interface{}, error) {
+	promConfigs, err := ConfigToPromConfigs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	names := PrometheusReceiverNames(promConfigs)
+
+	promConfig := make(map[interface{}]interface{})
+	for k, v := range promConfigs[names[0]] {
+		promConfig[k] = v
+	}
+	var scrapeConfigs []interface{}
+	receiverOfJob := make(map[string]string)
+	for i, name := range names {
+		if i > 0 && !sameGlobalSettings(promConfigs[names[0]], promConfigs[name]) {
+			return nil, fmt.Errorf("the global section of the %s receiver differs from the one of the %s receiver, only the scrape interval and timeout can differ across the prometheus receivers", name, names[0])
+		}
+		jobs, ok := promConfigs[name]["scrape_configs"]
+		if !ok || jobs == nil {
+			continue
+		}
+		jobList, ok := jobs.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("scrape_configs property of the %s receiver doesn't contain a valid list of scrape configs", name)
+		}
+		for _, job := range jobList {
+			scrapeConfig, ok := job.(map[interface{}]interface{})
+			if !ok {
+				return nil, fmt.Errorf("scrape_configs property of the %s receiver doesn't contain a valid list of scrape configs", name)
+			}
+			jobName, _ := scrapeConfig["job_name"].(string)
+			if other, ok := receiverOfJob[jobName]; ok && other == name {
+				return nil, fmt.Errorf("the job %q is configured more than once by the %s receiver", jobName, name)
+			} else if ok {
+				return nil, fmt.Errorf("the job %q is configured by both the %s and %s receivers, job names must be unique across the prometheus receivers", jobName, other, name)
+			}
+			receiverOfJob[jobName] = name
+
+			if i > 0 {
+				if scrapeConfig, err = withGlobalDefaults(scrapeConfig, promConfigs[name]); err != nil {
+					return nil, fmt.Errorf("the job %q of the %s receiver is invalid: %w", jobName, name, err)
+				}
+			}
+			scrapeConfigs = append(scrapeConfigs, scrapeConfig)
+		}
 	}
+	if _, ok := promConfig["scrape_configs"]; ok || len(scrapeConfigs) > 0 {
+		promConfig["scrape_configs"] = scrapeConfigs
+	}
+	return promConfig, nil
+}
 
-	prometheusConfig, ok := prometheusConfigProperty.(map[interface{}]interface{})
+// withGlobalDefaults returns a copy of the scrape config with the scrape interval and timeout it defaults to in its
+// Prometheus config, the timeout being at most the interval like in Prometheus.
+func withGlobalDefaults(scrapeConfig map[interface{}]interface{}, promConfig map[interface{}]interface{}) (map[interface{}]interface{}, error) {
+	global, _ := promConfig["global"].(map[interface{}]interface{})
+	withDefaults := make(map[interface{}]interface{}, len(scrapeConfig)+2)
+	for k, v := range scrapeConfig {
+		withDefaults[k] = v
+	}
+
+	interval, ok := scrapeConfig["scrape_interval"]
 	if !ok {
-		return nil, errorNotAMap("prometheusConfig")
+		interval = defaultScrapeInterval
+		if globalInterval, ok := global["scrape_interval"]; ok {
+			interval = globalInterval
+		}
+		withDefaults["scrape_interval"] = interval
+	}
+	if _, ok := scrapeConfig["scrape_timeout"]; ok {
+		return withDefaults, nil
+	}
+	timeout := interface{}(defaultScrapeTimeout)
+	if globalTimeout, ok := global["scrape_timeout"]; ok {
+		timeout = globalTimeout
 	}
 
-	return prometheusConfig, nil
+	parsedInterval, err := model.ParseDuration(fmt.Sprint(interval))
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrape interval: %w", err)
+	}
+	parsedTimeout, err := model.ParseDuration(fmt.Sprint(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrape timeout: %w", err)
+	}
+	if parsedTimeout > parsedInterval {
+		timeout = interval
+	}
+	withDefaults["scrape_timeout"] = timeout
+	return withDefaults, nil
+}
+
+// sameGlobalSettings returns whether the global sections of the Prometheus configs are the same, apart from the scrape
+// interval and timeout, which are set on the jobs.
+func sameGlobalSettings(promConfig map[interface{}]interface{}, other map[interface{}]interface{}) bool {
+	settings := func(promConfig map[interface{}]interface{}) map[interface{}]interface{} {
+		global, _ := promConfig["global"].(map[interface{}]interface{})
+		filtered := make(map[interface{}]interface{}, len(global))
+		for k, v := range global {
+			if k != "scrape_interval" && k != "scrape_timeout" {
+				filtered[k] = v
+			}
+		}
+		return filtered
+	}
+	return reflect.DeepEqual(settings(promConfig), settings(other))
 }
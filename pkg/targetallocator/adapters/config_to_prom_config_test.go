@@ -76,3 +76,166 @@ func TestExtractPromConfigFromNullConfig(t *testing.T) {
 	// verify
 	assert.True(t, reflect.ValueOf(promConfig).IsNil())
 }
+
+func TestExtractPromConfigFromMultipleReceivers(t *testing.T) {
+	configStr := `receivers:
+  examplereceiver:
+    endpoint: "0.0.0.0:12345"
+  prometheus/infra:
+    config:
+      global:
+        scrape_interval: 30s
+      scrape_configs:
+        - job_name: kubelet
+  prometheus/apps:
+    config:
+      scrape_configs:
+        - job_name: service-x
+          scrape_interval: 10s
+        - job_name: service-y
+`
+	// the global section is the one of the first receiver, the jobs of the other ones keep their scrape interval and timeout
+	expectedData := map[interface{}]interface{}{
+		"scrape_configs": []interface{}{
+			map[interface{}]interface{}{
+				"job_name":        "service-x",
+				"scrape_interval": "10s",
+			},
+			map[interface{}]interface{}{
+				"job_name": "service-y",
+			},
+			map[interface{}]interface{}{
+				"job_name":        "kubelet",
+				"scrape_interval": "30s",
+				"scrape_timeout":  "10s",
+			},
+		},
+	}
+
+	// test
+	promConfigs, err := ta.ConfigToPromConfigs(configStr)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prometheus/apps", "prometheus/infra"}, ta.PrometheusReceiverNames(promConfigs))
+
+	promConfig, err := ta.ConfigToPromConfig(configStr)
+	assert.NoError(t, err)
+
+	// verify
+	assert.Equal(t, expectedData, promConfig)
+}
+
+func TestExtractPromConfigWithGlobalDefaults(t *testing.T) {
+	configStr := `receivers:
+  prometheus:
+    config:
+      global:
+        scrape_interval: 1m
+        scrape_timeout: 30s
+        external_labels:
+          cluster: a
+      scrape_configs:
+        - job_name: service-x
+  prometheus/infra:
+    config:
+      global:
+        scrape_interval: 5s
+        scrape_timeout: 3s
+        external_labels:
+          cluster: a
+      scrape_configs:
+        - job_name: kubelet
+        - job_name: node
+          scrape_interval: 2s
+        - job_name: cadvisor
+          scrape_timeout: 1s
+`
+
+	promConfig, err := ta.ConfigToPromConfig(configStr)
+	assert.NoError(t, err)
+
+	scrapeConfigs := promConfig["scrape_configs"].([]interface{})
+	assert.Len(t, scrapeConfigs, 4)
+	assert.Equal(t, map[interface{}]interface{}{"job_name": "service-x"}, scrapeConfigs[0])
+	assert.Equal(t, map[interface{}]interface{}{"job_name": "kubelet", "scrape_interval": "5s", "scrape_timeout": "3s"}, scrapeConfigs[1])
+	// the timeout is at most the interval of the job
+	assert.Equal(t, map[interface{}]interface{}{"job_name": "node", "scrape_interval": "2s", "scrape_timeout": "2s"}, scrapeConfigs[2])
+	assert.Equal(t, map[interface{}]interface{}{"job_name": "cadvisor", "scrape_interval": "5s", "scrape_timeout": "1s"}, scrapeConfigs[3])
+}
+
+func TestExtractPromConfigWithConflictingGlobals(t *testing.T) {
+	configStr := `receivers:
+  prometheus:
+    config:
+      global:
+        external_labels:
+          cluster: a
+      scrape_configs:
+        - job_name: service-x
+  prometheus/infra:
+    config:
+      global:
+        external_labels:
+          cluster: b
+      scrape_configs:
+        - job_name: kubelet
+`
+
+	_, err := ta.ConfigToPromConfig(configStr)
+	assert.EqualError(t, err, "the global section of the prometheus/infra receiver differs from the one of the prometheus receiver, only the scrape interval and timeout can differ across the prometheus receivers")
+}
+
+func TestExtractPromConfigWithDuplicateJobs(t *testing.T) {
+	for _, tt := range []struct {
+		desc      string
+		configStr string
+		err       string
+	}{
+		{
+			desc: "across receivers",
+			configStr: `receivers:
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+  prometheus/infra:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+`,
+			err: `the job "kubelet" is configured by both the prometheus and prometheus/infra receivers, job names must be unique across the prometheus receivers`,
+		},
+		{
+			desc: "in a receiver",
+			configStr: `receivers:
+  prometheus:
+    config:
+      scrape_configs:
+        - job_name: kubelet
+        - job_name: kubelet
+`,
+			err: `the job "kubelet" is configured more than once by the prometheus receiver`,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := ta.ConfigToPromConfig(tt.configStr)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestExtractPromConfigFromInvalidReceiver(t *testing.T) {
+	configStr := `receivers:
+  prometheus/apps:
+`
+
+	// test
+	_, err := ta.ConfigToPromConfig(configStr)
+	assert.EqualError(t, err, "prometheus/apps property in the configuration doesn't contain valid prometheus/apps")
+}
+
+func TestIsPrometheusReceiver(t *testing.T) {
+	assert.True(t, ta.IsPrometheusReceiver("prometheus"))
+	assert.True(t, ta.IsPrometheusReceiver("prometheus/infra"))
+	assert.False(t, ta.IsPrometheusReceiver("prometheusexec"))
+	assert.False(t, ta.IsPrometheusReceiver("prometheus_simple/infra"))
+}
@@ -15,6 +15,8 @@
 package targetallocator
 
 import (
+	"fmt"
+
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 
@@ -50,6 +52,32 @@ func Container(cfg config.Config, logger logr.Logger, otelcol v1alpha1.OpenTelem
 	if otelcol.Spec.TargetAllocator.PrometheusCR.Enabled {
 		args = append(args, "--enable-prometheus-cr-watcher")
 	}
+	if len(otelcol.Spec.TargetAllocator.AllocationStrategy) > 0 {
+		args = append(args, fmt.Sprintf("--allocation-strategy=%s", otelcol.Spec.TargetAllocator.AllocationStrategy))
+	}
+	if tls := otelcol.Spec.TargetAllocator.TLS; tls != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      naming.TATLSVolume(),
+			MountPath: tlsMountPath,
+			ReadOnly:  true,
+		})
+		args = append(args,
+			fmt.Sprintf("--tls-cert-file=%s/%s", tlsMountPath, corev1.TLSCertKey),
+			fmt.Sprintf("--tls-key-file=%s/%s", tlsMountPath, corev1.TLSPrivateKeyKey),
+			fmt.Sprintf("--metrics-listen-addr=:%d", MetricsPort),
+		)
+		if len(tls.ClientCertSecretName) > 0 {
+			args = append(args, fmt.Sprintf("--tls-client-ca-file=%s/%s", tlsMountPath, caCertKey))
+		}
+	}
+	if otelcol.Spec.TargetAllocator.BearerTokenSecret != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      naming.TABearerTokenVolume(),
+			MountPath: bearerTokenMountPath,
+			ReadOnly:  true,
+		})
+		args = append(args, fmt.Sprintf("--bearer-token-file=%s/%s", bearerTokenMountPath, bearerTokenPath))
+	}
 	return corev1.Container{
 		Name:         naming.TAContainer(),
 		Image:        image,
@@ -77,3 +77,41 @@ func TestContainerVolumes(t *testing.T) {
 	assert.Len(t, c.VolumeMounts, 1)
 	assert.Equal(t, naming.TAConfigMapVolume(), c.VolumeMounts[0].Name)
 }
+
+func TestContainerAllocationStrategy(t *testing.T) {
+	// prepare
+	otelcol := v1alpha1.OpenTelemetryCollector{
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Enabled:            true,
+				AllocationStrategy: v1alpha1.OpenTelemetryTargetAllocatorAllocationStrategyConsistentHashing,
+			},
+		},
+	}
+	cfg := config.New()
+
+	// test
+	c := Container(cfg, logger, otelcol)
+
+	// verify
+	assert.Contains(t, c.Args, "--allocation-strategy=consistent-hashing")
+}
+
+func TestContainerTLSAndBearerToken(t *testing.T) {
+	// prepare
+	otelcol := securedCollector()
+	cfg := config.New()
+
+	// test
+	c := Container(cfg, logger, otelcol)
+
+	// verify
+	assert.Contains(t, c.Args, "--tls-cert-file=/tls/tls.crt")
+	assert.Contains(t, c.Args, "--tls-key-file=/tls/tls.key")
+	assert.Contains(t, c.Args, "--tls-client-ca-file=/tls/ca.crt")
+	assert.Contains(t, c.Args, "--bearer-token-file=/auth/token")
+	assert.Contains(t, c.Args, "--metrics-listen-addr=:8081")
+	assert.Len(t, c.VolumeMounts, 3)
+	assert.Equal(t, naming.TATLSVolume(), c.VolumeMounts[1].Name)
+	assert.Equal(t, naming.TABearerTokenVolume(), c.VolumeMounts[2].Name)
+}
@@ -31,6 +31,9 @@ func Deployment(cfg config.Config, logger logr.Logger, otelcol v1alpha1.OpenTele
 	labels["app.kubernetes.io/name"] = naming.TargetAllocator(otelcol)
 
 	var replicas int32 = 1
+	if otelcol.Spec.TargetAllocator.Replicas != nil {
+		replicas = *otelcol.Spec.TargetAllocator.Replicas
+	}
 
 	return appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -51,8 +54,28 @@ func Deployment(cfg config.Config, logger logr.Logger, otelcol v1alpha1.OpenTele
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{Container(cfg, logger, otelcol)},
 					Volumes:    Volumes(cfg, otelcol),
+					Affinity:   affinity(replicas, labels),
 				},
 			},
 		},
 	}
 }
+
+// affinity spreads the replicas of the TargetAllocator over the nodes, so that a single node going down
+// doesn't take all of them.
+func affinity(replicas int32, labels map[string]string) *corev1.Affinity {
+	if replicas < 2 {
+		return nil
+	}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   "kubernetes.io/hostname",
+				},
+			}},
+		},
+	}
+}
@@ -69,3 +69,48 @@ func TestDeploymentPodAnnotations(t *testing.T) {
 	assert.Equal(t, "my-instance-targetallocator", ds.Name)
 	assert.Equal(t, testPodAnnotationValues, ds.Spec.Template.Annotations)
 }
+
+func TestDeploymentReplicas(t *testing.T) {
+	// prepare
+	replicas := int32(3)
+	otelcol := v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-instance",
+		},
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Replicas: &replicas,
+			},
+		},
+	}
+	cfg := config.New()
+
+	// test
+	d := Deployment(cfg, logger, otelcol)
+
+	// verify
+	assert.Equal(t, int32(3), *d.Spec.Replicas)
+
+	// the replicas should be spread over the nodes
+	terms := d.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	assert.Len(t, terms, 1)
+	assert.Equal(t, "kubernetes.io/hostname", terms[0].PodAffinityTerm.TopologyKey)
+	assert.Equal(t, d.Spec.Selector.MatchLabels, terms[0].PodAffinityTerm.LabelSelector.MatchLabels)
+}
+
+func TestDeploymentSingleReplica(t *testing.T) {
+	// prepare
+	otelcol := v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-instance",
+		},
+	}
+	cfg := config.New()
+
+	// test
+	d := Deployment(cfg, logger, otelcol)
+
+	// verify
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+	assert.Nil(t, d.Spec.Template.Spec.Affinity)
+}
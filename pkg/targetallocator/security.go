@@ -0,0 +1,150 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package targetallocator
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+)
+
+const (
+	tlsMountPath         = "/tls"
+	bearerTokenMountPath = "/auth"
+	bearerTokenPath      = "token"
+	caCertKey            = "ca.crt"
+
+	collectorCAMountPath          = "/etc/otel-targetallocator/tls"
+	collectorClientTLSMountPath   = "/etc/otel-targetallocator/client"
+	collectorBearerTokenMountPath = "/etc/otel-targetallocator/auth"
+
+	// CollectorCAFile is the path of the CA certificate used by the collectors to verify the TargetAllocator.
+	CollectorCAFile = collectorCAMountPath + "/" + caCertKey
+
+	// CollectorCertFile is the path of the certificate the collectors present to the TargetAllocator.
+	CollectorCertFile = collectorClientTLSMountPath + "/" + corev1.TLSCertKey
+
+	// CollectorKeyFile is the path of the key of the certificate the collectors present to the TargetAllocator.
+	CollectorKeyFile = collectorClientTLSMountPath + "/" + corev1.TLSPrivateKeyKey
+
+	// CollectorBearerTokenFile is the path of the token the collectors present to the TargetAllocator.
+	CollectorBearerTokenFile = collectorBearerTokenMountPath + "/" + bearerTokenPath
+
+	// MetricsPort is the port the TargetAllocator serves its metrics on over plain HTTP, when its endpoints are served
+	// over HTTPS, so that they can be scraped without a client certificate.
+	MetricsPort int32 = 8081
+)
+
+// ServicePort returns the port of the TargetAllocator's service.
+func ServicePort(otelcol v1alpha1.OpenTelemetryCollector) int32 {
+	if otelcol.Spec.TargetAllocator.TLS != nil {
+		return 443
+	}
+	return 80
+}
+
+// Endpoint returns the URL the collectors reach the TargetAllocator at.
+func Endpoint(otelcol v1alpha1.OpenTelemetryCollector) string {
+	scheme := "http"
+	if otelcol.Spec.TargetAllocator.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, naming.TAService(otelcol), ServicePort(otelcol))
+}
+
+// CollectorVolumes builds the volumes the collectors need to connect to the TargetAllocator.
+func CollectorVolumes(otelcol v1alpha1.OpenTelemetryCollector) []corev1.Volume {
+	if !otelcol.Spec.TargetAllocator.Enabled {
+		return nil
+	}
+
+	var volumes []corev1.Volume
+	if tls := otelcol.Spec.TargetAllocator.TLS; tls != nil {
+		// only the CA certificate is needed, the TargetAllocator's key must not be shared with the collectors
+		volumes = append(volumes, corev1.Volume{
+			Name: naming.TACAVolume(),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tls.SecretName,
+					Items: []corev1.KeyToPath{{
+						Key:  caCertKey,
+						Path: caCertKey,
+					}},
+				},
+			},
+		})
+		if len(tls.ClientCertSecretName) > 0 {
+			volumes = append(volumes, corev1.Volume{
+				Name: naming.TAClientTLSVolume(),
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: tls.ClientCertSecretName},
+				},
+			})
+		}
+	}
+	if token := otelcol.Spec.TargetAllocator.BearerTokenSecret; token != nil {
+		volumes = append(volumes, bearerTokenVolume(naming.TAClientBearerTokenVolume(), token))
+	}
+	return volumes
+}
+
+// CollectorVolumeMounts builds the volume mounts matching the volumes returned by CollectorVolumes.
+func CollectorVolumeMounts(otelcol v1alpha1.OpenTelemetryCollector) []corev1.VolumeMount {
+	if !otelcol.Spec.TargetAllocator.Enabled {
+		return nil
+	}
+
+	var volumeMounts []corev1.VolumeMount
+	if tls := otelcol.Spec.TargetAllocator.TLS; tls != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      naming.TACAVolume(),
+			MountPath: collectorCAMountPath,
+			ReadOnly:  true,
+		})
+		if len(tls.ClientCertSecretName) > 0 {
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{
+				Name:      naming.TAClientTLSVolume(),
+				MountPath: collectorClientTLSMountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+	if otelcol.Spec.TargetAllocator.BearerTokenSecret != nil {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      naming.TAClientBearerTokenVolume(),
+			MountPath: collectorBearerTokenMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumeMounts
+}
+
+func bearerTokenVolume(name string, token *corev1.SecretKeySelector) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: token.Name,
+				Items: []corev1.KeyToPath{{
+					Key:  token.Key,
+					Path: bearerTokenPath,
+				}},
+			},
+		},
+	}
+}
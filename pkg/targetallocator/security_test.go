@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package targetallocator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/open-telemetry/opentelemetry-operator/apis/v1alpha1"
+	"github.com/open-telemetry/opentelemetry-operator/pkg/naming"
+)
+
+func securedCollector() v1alpha1.OpenTelemetryCollector {
+	return v1alpha1.OpenTelemetryCollector{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-instance",
+		},
+		Spec: v1alpha1.OpenTelemetryCollectorSpec{
+			TargetAllocator: v1alpha1.OpenTelemetryTargetAllocator{
+				Enabled: true,
+				TLS: &v1alpha1.OpenTelemetryTargetAllocatorTLS{
+					SecretName:           "ta-cert",
+					ClientCertSecretName: "collector-cert",
+				},
+				BearerTokenSecret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ta-token"},
+					Key:                  "my-token",
+				},
+			},
+		},
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	otelcol := securedCollector()
+	assert.Equal(t, "https://my-instance-targetallocator:443", Endpoint(otelcol))
+
+	otelcol.Spec.TargetAllocator.TLS = nil
+	assert.Equal(t, "http://my-instance-targetallocator:80", Endpoint(otelcol))
+}
+
+func TestCollectorVolumes(t *testing.T) {
+	// prepare
+	otelcol := securedCollector()
+
+	// test
+	volumes := CollectorVolumes(otelcol)
+	volumeMounts := CollectorVolumeMounts(otelcol)
+
+	// verify
+	assert.Equal(t, []corev1.Volume{
+		{
+			Name: naming.TACAVolume(),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "ta-cert",
+					Items:      []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+				},
+			},
+		},
+		{
+			Name: naming.TAClientTLSVolume(),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: "collector-cert"},
+			},
+		},
+		{
+			Name: naming.TAClientBearerTokenVolume(),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "ta-token",
+					Items:      []corev1.KeyToPath{{Key: "my-token", Path: "token"}},
+				},
+			},
+		},
+	}, volumes)
+	assert.Len(t, volumeMounts, 3)
+	for i := range volumes {
+		assert.Equal(t, volumes[i].Name, volumeMounts[i].Name)
+	}
+}
+
+func TestCollectorVolumesTargetAllocatorDisabled(t *testing.T) {
+	// prepare
+	otelcol := securedCollector()
+	otelcol.Spec.TargetAllocator.Enabled = false
+
+	// test and verify
+	assert.Empty(t, CollectorVolumes(otelcol))
+	assert.Empty(t, CollectorVolumeMounts(otelcol))
+}
@@ -38,5 +38,17 @@ func Volumes(cfg config.Config, otelcol v1alpha1.OpenTelemetryCollector) []corev
 		},
 	}}
 
+	if tls := otelcol.Spec.TargetAllocator.TLS; tls != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: naming.TATLSVolume(),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: tls.SecretName},
+			},
+		})
+	}
+	if token := otelcol.Spec.TargetAllocator.BearerTokenSecret; token != nil {
+		volumes = append(volumes, bearerTokenVolume(naming.TABearerTokenVolume(), token))
+	}
+
 	return volumes
 }
@@ -41,3 +41,19 @@ func TestVolumeNewDefault(t *testing.T) {
 	// check that it's the ta-internal volume, with the config map
 	assert.Equal(t, naming.TAConfigMapVolume(), volumes[0].Name)
 }
+
+func TestVolumeTLSAndBearerToken(t *testing.T) {
+	// prepare
+	otelcol := securedCollector()
+	cfg := config.New()
+
+	// test
+	volumes := Volumes(cfg, otelcol)
+
+	// verify
+	assert.Len(t, volumes, 3)
+	assert.Equal(t, naming.TATLSVolume(), volumes[1].Name)
+	assert.Equal(t, "ta-cert", volumes[1].Secret.SecretName)
+	assert.Equal(t, naming.TABearerTokenVolume(), volumes[2].Name)
+	assert.Equal(t, "ta-token", volumes[2].Secret.SecretName)
+}